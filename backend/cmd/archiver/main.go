@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	_ "time/tzdata"
+
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	applog "github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+// Archives a single day's Redis polls into Postgres and exits.
+//
+//	go run ./cmd/archiver -date 2025-04-07
+func main() {
+	date := flag.String("date", "", "poll day to archive (YYYY-MM-DD, default yesterday in APP_TIMEZONE)")
+	flag.Parse()
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+	util.SetLocation(config.Location)
+	if *date == "" {
+		*date = util.YesterdayDate()
+	}
+
+	logger := applog.Initialize(config.AppEnv)
+	defer applog.Sync()
+
+	database := db.InitDB(*config)
+	if database == nil {
+		log.Fatal("cannot connect to database")
+	}
+	if config.RedisConfig.Driver != "redis" {
+		log.Fatal("the archiver reads live polls from Redis, set CACHE_DRIVER=redis")
+	}
+	cacheService := db.NewRedisCacheService(*config)
+
+	archiver := service.NewArchiverService(repository.NewQuestionRepository(database, logger), repository.NewVoteRepository(database, logger), cacheService, logger)
+	count, err := archiver.ArchiveDate(context.Background(), *date)
+	if err != nil {
+		log.Fatalf("archived %d question(s) for %s with errors: %v", count, *date, err)
+	}
+	log.Printf("archived %d question(s) for %s", count, *date)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
+	_ "time/tzdata" // APP_TIMEZONE must resolve in the Alpine image
 
 	"github.com/guncv/Poll-Voting-Website/backend/config"
 	"github.com/guncv/Poll-Voting-Website/backend/controller"
 	"github.com/guncv/Poll-Voting-Website/backend/db"
+	applog "github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
 )
 
 func main() {
@@ -13,9 +19,30 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
+	util.SetLocation(config.Location)
 
 	database := db.InitDB(*config)
-	cacheService := db.NewRedisCacheService(*config)
+	if database == nil {
+		log.Fatal("cannot connect to database")
+	}
+	if config.DB.AutoMigrate {
+		migrator, err := db.NewMigrator(database)
+		if err != nil {
+			log.Fatal("cannot load migrations:", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("cannot migrate database:", err)
+		}
+	}
+	if err := db.VerifySchema(database); err != nil {
+		log.Fatal("database schema does not match models, run cmd/migrate up: ", err)
+	}
+	cacheService := db.NewCacheService(*config)
+
+	// Nightly job moving yesterday's Redis polls into the questions table.
+	logger := applog.Initialize(config.AppEnv)
+	archiver := service.NewArchiverService(repository.NewQuestionRepository(database, logger), repository.NewVoteRepository(database, logger), cacheService, logger)
+	go archiver.Start(context.Background())
 
 	server := controller.NewServer(*config, database, cacheService)
 
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+)
+
+// Applies or rolls back the embedded schema migrations and exits.
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down [steps]
+//	go run ./cmd/migrate version
+//	go run ./cmd/migrate seed
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | version | seed")
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	database := db.InitDB(*config)
+	if database == nil {
+		log.Fatal("cannot connect to database")
+	}
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		log.Fatal("cannot load migrations:", err)
+	}
+	ctx := context.Background()
+
+	switch flag.Arg(0) {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("applied %d migration(s) before failing: %v", count, err)
+		}
+		log.Printf("applied %d migration(s)", count)
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatal("steps must be a positive number")
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("reverted %d migration(s) before failing: %v", count, err)
+		}
+		log.Printf("reverted %d migration(s)", count)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal("cannot read schema version:", err)
+		}
+		log.Printf("schema version %d (latest %d)", version, migrator.Latest())
+	case "seed":
+		if err := db.Seed(ctx, database); err != nil {
+			log.Fatal("cannot seed database:", err)
+		}
+		log.Print("seeded development data")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
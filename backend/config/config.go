@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,6 +17,8 @@ type DBConfig struct {
 	Password string `mapstructure:"DB_PASSWORD"`
 	Name     string `mapstructure:"DB_NAME"`
 	SSLMode  string `mapstructure:"DB_SSLMODE"` // new field for SSL mode
+	// AutoMigrate applies pending migrations when the server starts.
+	AutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
 }
 
 type NotificationConfig struct {
@@ -24,23 +28,53 @@ type NotificationConfig struct {
 	SessionToken  string `mapstructure:"SNS_SESSION_TOKEN"`
 	AdminTopicArn string `mapstructure:"ADMIN_TOPIC_ARN"`
 	UserTopicArn  string `mapstructure:"USER_TOPIC_ARN"`
+
+	// Channels lists the enabled backends: sns, smtp, webhook, slack, log.
+	Channels string `mapstructure:"NOTIFICATION_CHANNELS"`
+
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPFrom     string `mapstructure:"SMTP_FROM"`
+	SMTPAdminTo  string `mapstructure:"SMTP_ADMIN_TO"` // comma-separated
+	SMTPUserTo   string `mapstructure:"SMTP_USER_TO"`  // comma-separated
+
+	WebhookURL      string `mapstructure:"WEBHOOK_URL"`
+	WebhookSecret   string `mapstructure:"WEBHOOK_SECRET"`
+	SlackWebhookURL string `mapstructure:"SLACK_WEBHOOK_URL"`
 }
 
 type RedisConfig struct {
+	// Driver selects the cache: redis, or memory for a single-node instance
+	// whose live polls and sessions are lost on restart.
+	Driver   string `mapstructure:"CACHE_DRIVER"`
 	Host     string `mapstructure:"REDIS_HOST"`
 	Port     string `mapstructure:"REDIS_PORT"`
 	Password string `mapstructure:"REDIS_PASSWORD"`
 	DB       int    `mapstructure:"REDIS_DB"`
 }
 
+// JWTConfig selects the access/refresh token signing keys.
+type JWTConfig struct {
+	Algorithm   string `mapstructure:"JWT_ALGORITHM"`  // HS256, RS256 or EdDSA
+	ActiveKeyID string `mapstructure:"JWT_ACTIVE_KID"` // kid used to sign new tokens
+	Secrets     string `mapstructure:"JWT_SECRETS"`    // HS256 only: "kid:secret,kid:secret"
+	KeysDir     string `mapstructure:"JWT_KEYS_DIR"`   // RS256/EdDSA only: <kid>.pem files
+}
+
 // Config is the main configuration struct for your application.
 type Config struct {
 	DB            DBConfig           `mapstructure:",squash"`
 	RedisConfig   RedisConfig        `mapstructure:",squash"`
 	Notification  NotificationConfig `mapstructure:",squash"`
+	JWT           JWTConfig          `mapstructure:",squash"`
 	AppEnv        string             `mapstructure:"APP_ENV"`
 	ServerAddress string             `mapstructure:"SERVER_ADDRESS"`
 	CorsECSDomain string             `mapstructure:"CORS_ECS_DOMAIN"`
+	// Timezone is the IANA zone poll days roll over in, e.g. Asia/Bangkok.
+	Timezone string         `mapstructure:"APP_TIMEZONE"`
+	Location *time.Location `mapstructure:"-"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -48,6 +82,23 @@ func LoadConfig() (*Config, error) {
 	viper.AddConfigPath(".")
 	viper.AutomaticEnv()
 
+	// Defaults also let AutomaticEnv pick these keys up when .env omits them.
+	viper.SetDefault("DB_AUTO_MIGRATE", true)
+	viper.SetDefault("NOTIFICATION_CHANNELS", "sns")
+	for _, key := range []string{
+		"SMTP_HOST", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_FROM", "SMTP_ADMIN_TO", "SMTP_USER_TO",
+		"WEBHOOK_URL", "WEBHOOK_SECRET", "SLACK_WEBHOOK_URL",
+	} {
+		viper.SetDefault(key, "")
+	}
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
+	viper.SetDefault("JWT_ACTIVE_KID", "")
+	viper.SetDefault("JWT_SECRETS", "")
+	viper.SetDefault("JWT_KEYS_DIR", "keys")
+	viper.SetDefault("APP_TIMEZONE", "Local")
+	viper.SetDefault("CACHE_DRIVER", "redis")
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Error reading config file, %s", err)
 		return nil, err
@@ -58,5 +109,15 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	location, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APP_TIMEZONE %q: %w", config.Timezone, err)
+	}
+	config.Location = location
+
+	if config.RedisConfig.Driver != "redis" && config.RedisConfig.Driver != "memory" {
+		return nil, fmt.Errorf("invalid CACHE_DRIVER %q: use redis or memory", config.RedisConfig.Driver)
+	}
+
 	return &config, nil
 }
@@ -8,4 +8,61 @@ const (
 	ParticipantsReachedThreshold = 1
 	HTTPTimeout                  = 30 * time.Second
 	SNSRegion                    = "ap-southeast-1"
+
+	// Redis poll keys outlive the first archive run after the poll closes by
+	// ArchiveGracePeriod so the archiver can still read the final tallies.
+	ArchiveGracePeriod = 2 * time.Hour
+	ArchiveRunHour     = 0
+	ArchiveRunMinute   = 5
+
+	// The scheduler opens due polls every ScheduleInterval, at most
+	// ScheduleBatchSize per run, and retries a poll it failed to open once its
+	// ScheduleLease runs out. Polls can be scheduled MaxScheduleAhead ahead.
+	ScheduleInterval  = 15 * time.Second
+	ScheduleBatchSize = 50
+	ScheduleLease     = time.Minute
+	MaxScheduleAhead  = 30 * 24 * time.Hour
+	MaxPollDuration   = 24 * time.Hour
+
+	MinQuestionOptions = 2
+	MaxQuestionOptions = 10
+	MaxMilestones      = 10
+
+	// Keeps live tally streams alive below the ALB's 60s idle timeout.
+	StreamHeartbeatInterval = 15 * time.Second
+	// Stream tickets stand in for the access token in the query string of
+	// live tally streams and must be redeemed within StreamTicketTTL.
+	StreamTicketTTL = 30 * time.Second
+
+	// Notification outbox dispatch. A failed message is retried after
+	// OutboxBaseBackoff, doubling up to OutboxMaxBackoff, and is marked dead
+	// after OutboxMaxAttempts.
+	OutboxPollInterval = 5 * time.Second
+	OutboxBatchSize    = 20
+	OutboxLease        = 2 * time.Minute
+	OutboxMaxAttempts  = 8
+	OutboxBaseBackoff  = 30 * time.Second
+	OutboxMaxBackoff   = time.Hour
+
+	// Handlers give up on Postgres and Redis after RequestTimeout, well
+	// within the ALB's 60s idle timeout.
+	RequestTimeout = 10 * time.Second
+
+	// Readiness checks give up after HealthCheckTimeout, well within the
+	// ALB's 5s health check timeout.
+	HealthCheckTimeout = 2 * time.Second
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// A rotated refresh token may be presented once more within
+	// RefreshReuseGrace, so tabs refreshing at the same time do not look like
+	// token theft.
+	RefreshReuseGrace = 10 * time.Second
+)
+
+// Roles stored in users.role and embedded in access tokens.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
 )
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+)
+
+// GrantRole handles PUT /admin/users/:id/role
+func (s *Server) GrantRole(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GrantRole] Called")
+
+	var req entity.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GrantRole] Error parsing request body:", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	return s.setRole(c, "GrantRole", req.Role)
+}
+
+// RevokeRole handles DELETE /admin/users/:id/role and resets the user to the
+// default role.
+func (s *Server) RevokeRole(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: RevokeRole] Called")
+	return s.setRole(c, "RevokeRole", constant.RoleUser)
+}
+
+func (s *Server) setRole(c *fiber.Ctx, handler, role string) error {
+	id := c.Params("id")
+	if id == "" {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] Missing user ID")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	// Admins cannot change their own role, so the last admin cannot lock
+	// everyone out by accident.
+	if actorID, _ := c.Locals("userID").(string); actorID == id {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] Refusing to change own role")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Cannot change your own role"})
+	}
+
+	u, err := s.userService.SetRole(c.UserContext(), id, role)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRole) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] Invalid role:", role)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err.Error() == "user not found" {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] User not found with id:", id)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] Service error:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.logger.AuditWithID(c.UserContext(), "role_changed", "user_id", id, "role", role, "by", c.Locals("userID"))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"user_id": u.UserID,
+		"email":   u.Email,
+		"role":    u.Role,
+	})
+}
+
+// ListDeadNotifications handles GET /admin/notifications/dead
+func (s *Server) ListDeadNotifications(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: ListDeadNotifications] Called")
+
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and 500"})
+	}
+
+	msgs, err := s.outboxService.ListDeadLetters(c.UserContext(), limit)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: ListDeadNotifications] Service error:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(msgs)
+}
+
+// RetryDeadNotification handles POST /admin/notifications/:id/retry
+func (s *Server) RetryDeadNotification(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: RetryDeadNotification] Called")
+
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: RetryDeadNotification] Invalid notification ID:", idParam)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+
+	if err := s.outboxService.Retry(c.UserContext(), id); err != nil {
+		if errors.Is(err, service.ErrOutboxMessageNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Dead notification not found"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: RetryDeadNotification] Service error:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	s.logger.AuditWithID(c.UserContext(), "notification_requeued", "id", id, "by", c.Locals("userID"))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Notification queued for retry"})
+}
@@ -2,61 +2,147 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/guncv/Poll-Voting-Website/backend/util"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
 )
 
 // JWTMiddleware validates the access token and sets the user ID in the context.
-func JWTMiddleware(c *fiber.Ctx) error {
-	// Log that the middleware is called.
-	fmt.Println("[JWTMiddleware] Called")
+func (s *Server) JWTMiddleware(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: JWTMiddleware] Called")
 
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
-		fmt.Println("[JWTMiddleware] Missing Authorization header")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: JWTMiddleware] Missing Authorization header")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing Authorization header"})
 	}
 
 	var tokenStr string
 	_, err := fmt.Sscanf(authHeader, "Bearer %s", &tokenStr)
 	if err != nil || tokenStr == "" {
-		fmt.Println("[JWTMiddleware] Invalid token format")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: JWTMiddleware] Invalid token format")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token format"})
 	}
 
 	// Validate the access token.
-	token, err := util.ValidateAccessToken(tokenStr)
+	token, err := s.jwt.ValidateAccessToken(tokenStr)
 	if err != nil || !token.Valid {
-		fmt.Println("[JWTMiddleware] Invalid or expired token:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: JWTMiddleware] Invalid or expired token:", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired token"})
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		fmt.Println("[JWTMiddleware] Invalid token claims")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: JWTMiddleware] Invalid token claims")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
 	}
 
-	userID := claims["sub"].(string)
-	fmt.Println("[JWTMiddleware] Setting userID in context:", userID)
+	userID, ok := claims["sub"].(string)
+	if !ok || userID == "" {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: JWTMiddleware] Token has no subject")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
+	}
+	role, _ := claims["role"].(string)
+	if role == "" {
+		// Tokens issued before roles existed carry no role claim.
+		role = constant.RoleUser
+	}
+	s.logger.InfoWithID(c.UserContext(), "[Controller: JWTMiddleware] Setting userID in context:", userID)
+
+	setIdentity(c, userID, role)
+	return c.Next()
+}
 
+// setIdentity records the authenticated user for the rest of the request.
+func setIdentity(c *fiber.Ctx, userID, role string) {
 	// ✅ Inject userID into the request context for logging and downstream usage
-	ctx := context.WithValue(c.Context(), "userID", userID)
+	ctx := context.WithValue(c.UserContext(), "userID", userID)
 	c.SetUserContext(ctx)
 
 	// You can still use Locals if needed for non-context use
 	c.Locals("userID", userID)
+	c.Locals("role", role)
+}
 
-	return c.Next()
+// JWKS handles GET /.well-known/jwks.json.
+// It publishes the public keys other services need to verify our access tokens.
+func (s *Server) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(s.jwt.JWKS())
+}
+
+// RequireRole only lets requests through whose access token carries one of
+// roles. It must run after JWTMiddleware.
+func (s *Server) RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !slices.Contains(roles, role) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: RequireRole] Forbidden for role:", role)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Insufficient role"})
+		}
+		return c.Next()
+	}
+}
+
+var (
+	errUnauthenticated = errors.New("unauthenticated")
+	errImpersonation   = errors.New("request identity does not match the authenticated user")
+)
+
+// requestUserID returns the user ID that JWTMiddleware took from the access
+// token. claimedID is whatever identity the request body asserts (user_id,
+// created_by, ...): it may be empty, but any other value than the token's
+// subject is rejected and recorded in the audit log.
+func (s *Server) requestUserID(c *fiber.Ctx, handler, claimedID string) (string, error) {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: "+handler+"] Missing user ID in context")
+		return "", errUnauthenticated
+	}
+
+	if claimedID != "" && claimedID != userID {
+		s.logger.AuditWithID(c.UserContext(), "impersonation_attempt",
+			"handler", handler,
+			"user_id", userID,
+			"claimed_user_id", claimedID,
+			"method", c.Method(),
+			"path", c.Path(),
+			"ip", c.IP(),
+		)
+		return "", errImpersonation
+	}
+
+	return userID, nil
+}
+
+// requestActor returns the authenticated caller for ownership checks.
+func (s *Server) requestActor(c *fiber.Ctx, handler string) (entity.Actor, error) {
+	userID, err := s.requestUserID(c, handler, "")
+	if err != nil {
+		return entity.Actor{}, err
+	}
+	role, _ := c.Locals("role").(string)
+	return entity.Actor{UserID: userID, Role: role}, nil
+}
+
+// identityError writes the response for an error returned by requestUserID.
+func identityError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errImpersonation) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Cannot act on behalf of another user"})
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 }
 
 // Login handles user login.
 func (s *Server) Login(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: Login] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Login] Called")
 
 	// Parse credentials from request.
 	var req struct {
@@ -64,51 +150,73 @@ func (s *Server) Login(c *fiber.Ctx) error {
 		Password string `json:"password"`
 	}
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Login] Error parsing request body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Login] Error parsing request body:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: Login] Request parsed for email:", req.Email)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Login] Request parsed for email:", req.Email)
 
 	// Authenticate user.
-	user, err := s.userService.Login(c.Context(), req.Email, req.Password)
+	user, err := s.userService.Login(c.UserContext(), req.Email, req.Password)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Login] Authentication failed:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Login] Authentication failed:", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: Login] User authenticated:", req.Email)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Login] User authenticated:", req.Email)
 
 	// Generate tokens.
-	accessToken, err := util.GenerateAccessToken(user.UserID.String())
+	accessToken, err := s.jwt.GenerateAccessToken(user.UserID.String(), user.Role)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Login] Error generating access token:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Login] Error generating access token:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not generate access token"})
 	}
-	refreshToken, err := util.GenerateRefreshToken(user.UserID.String())
+	refreshToken, err := s.tokenService.Issue(c.UserContext(), user.UserID.String())
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Login] Error generating refresh token:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not generate refresh token"})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Login] Error generating refresh token:", err)
+		return serverError(c, err, "Could not generate refresh token")
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: Login] Tokens generated for user:", req.Email)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Login] Tokens generated for user:", req.Email)
 
 	// Set the refresh token in an HttpOnly cookie.
+	setRefreshCookie(c, refreshToken)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Login] Refresh token cookie set for user:", req.Email)
+
+	// Return the access token in the JSON response.
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"access_token": accessToken,
+	})
+}
+
+// refreshCookiePath scopes the refresh cookie to the routes that consume it.
+const refreshCookiePath = "/api/user"
+
+func setRefreshCookie(c *fiber.Ctx, refreshToken string) {
 	c.Cookie(&fiber.Cookie{
 		Name:     "refresh_token",
 		Value:    refreshToken,
 		HTTPOnly: true,
-		Secure:   true,              
-		SameSite: "Lax",             
-		Path:     "/refresh",       
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
+		Secure:   true,
+		SameSite: "Lax",
+		Path:     refreshCookiePath,
+		Expires:  time.Now().Add(constant.RefreshTokenTTL),
 	})
-	s.logger.InfoWithID(c.Context(), "[Controller: Login] Refresh token cookie set for user:", req.Email)
+}
 
-	// Return the access token in the JSON response.
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"access_token": accessToken,
+// clearRefreshCookie expires the refresh cookie by setting its value to empty
+// and its expiration date to a time in the past.
+func clearRefreshCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Path:     refreshCookiePath,
 	})
 }
 
-// Refresh handles refreshing the access token using the refresh token stored in the cookie.
+// Refresh exchanges the refresh token stored in the cookie for a new access
+// token and a new refresh token. The old refresh token stops working.
 func (s *Server) Refresh(c *fiber.Ctx) error {
 	// Use the user-aware context from Fiber
 	ctx := c.UserContext()
@@ -121,28 +229,32 @@ func (s *Server) Refresh(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "No refresh token provided"})
 	}
 
-	// Validate the refresh token
-	token, err := util.ValidateRefreshToken(refreshToken)
-	if err != nil || !token.Valid {
-		s.logger.ErrorWithID(ctx, "[Controller: Refresh] Invalid refresh token:", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		s.logger.ErrorWithID(ctx, "[Controller: Refresh] Invalid refresh token claims")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token claims"})
+	userID, newRefreshToken, err := s.tokenService.Rotate(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			s.logger.ErrorWithID(ctx, "[Controller: Refresh] Rejected refresh token:", err)
+			clearRefreshCookie(c)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+		}
+		s.logger.ErrorWithID(ctx, "[Controller: Refresh] Error rotating refresh token:", err)
+		return serverError(c, err, "Could not refresh token")
 	}
-
-	userID := claims["sub"].(string)
-	s.logger.InfoWithID(ctx, "[Controller: Refresh] Refresh token validated for user:", userID)
+	s.logger.InfoWithID(ctx, "[Controller: Refresh] Refresh token rotated for user:", userID)
+	setRefreshCookie(c, newRefreshToken)
 
 	// ✅ Inject userID into context for downstream use
 	ctx = context.WithValue(ctx, "userID", userID)
 	c.SetUserContext(ctx)
 
+	// Look the user up again so role changes apply on the next refresh.
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		s.logger.ErrorWithID(ctx, "[Controller: Refresh] Error loading user:", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid refresh token"})
+	}
+
 	// Generate new access token
-	newAccessToken, err := util.GenerateAccessToken(userID)
+	newAccessToken, err := s.jwt.GenerateAccessToken(userID, user.Role)
 	if err != nil {
 		s.logger.ErrorWithID(ctx, "[Controller: Refresh] Error generating new access token:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not generate new access token"})
@@ -154,49 +266,64 @@ func (s *Server) Refresh(c *fiber.Ctx) error {
 	})
 }
 
-
-// Logout clears the refresh token cookie.
+// Logout revokes the session of the refresh token in the cookie and clears it.
 func (s *Server) Logout(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: Logout] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Logout] Called")
 
-	// Clear the refresh token cookie by setting its value to empty
-	// and its expiration date to a time in the past.
-	c.Cookie(&fiber.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Lax",
-	})
+	if refreshToken := c.Cookies("refresh_token"); refreshToken != "" {
+		// An invalid or expired token has nothing left to revoke.
+		if err := s.tokenService.Revoke(c.UserContext(), refreshToken); err != nil && !errors.Is(err, service.ErrInvalidRefreshToken) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: Logout] Error revoking refresh token:", err)
+			return serverError(c, err, "Could not revoke session")
+		}
+	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: Logout] Refresh token cookie cleared")
+	clearRefreshCookie(c)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Logout] Refresh token cookie cleared")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logout successful"})
 }
 
+// LogoutAll revokes every refresh token of the authenticated user.
+func (s *Server) LogoutAll(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: LogoutAll] Called")
+
+	userID, err := s.requestUserID(c, "LogoutAll", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	if err := s.tokenService.RevokeAll(c.UserContext(), userID); err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: LogoutAll] Error revoking sessions:", err)
+		return serverError(c, err, "Could not revoke sessions")
+	}
+
+	clearRefreshCookie(c)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: LogoutAll] All sessions revoked for user:", userID)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logged out everywhere"})
+}
 
 // Profile returns the authenticated user's profile.
 func (s *Server) Profile(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: Profile] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Profile] Called")
 
 	// Extract the userID from the context (set by the JWT middleware) as a string.
 	userIDStr, ok := c.Locals("userID").(string)
 	if !ok || userIDStr == "" {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Profile] No userID found in context")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Profile] No userID found in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: Profile] Retrieved userID from context:", userIDStr)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Profile] Retrieved userID from context:", userIDStr)
 
 	// Call the service to get the user profile, passing the userID as a string.
-	user, err := s.userService.GetUserByID(c.Context(), userIDStr)
+	user, err := s.userService.GetUserByID(c.UserContext(), userIDStr)
 	if err != nil {
 		if err.Error() == "user not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: Profile] User not found for id:", userIDStr)
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: Profile] User not found for id:", userIDStr)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: Profile] Service error:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Profile] Service error:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: Profile] Retrieved profile for user:", userIDStr)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Profile] Retrieved profile for user:", userIDStr)
 	return c.Status(fiber.StatusOK).JSON(user)
 }
@@ -1,21 +1,43 @@
 package controller
 
 import (
+	"context"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
 	"github.com/guncv/Poll-Voting-Website/backend/util"
 )
 
+// serverError answers 503 when err comes from an unreachable cache or the
+// request ran out of time, so clients know to retry, and 500 with message
+// otherwise.
+func serverError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, db.ErrCacheUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Service temporarily unavailable, please retry"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+}
+
 func (s *Server) getCache(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: getCache] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: getCache] Called")
 
 	key := c.Params("key")
-	date := util.TodayDate()
+	date, err := s.cache.Get(c.UserContext(), service.QuestionDayKey(key))
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: getCache] Redis error:", err)
+		return serverError(c, err, err.Error())
+	}
+	if date == "" {
+		date = util.TodayDate()
+	}
 	fullKey := "question:" + date + ":" + key
 
-	result, err := s.cache.GetAllHash(fullKey)
+	result, err := s.cache.GetAllHash(c.UserContext(), fullKey)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: getCache] Redis error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: getCache] Redis error:", err)
+		return serverError(c, err, err.Error())
 	}
 
 	if len(result) == 0 {
@@ -28,9 +50,10 @@ func (s *Server) getCache(c *fiber.Ctx) error {
 func (s *Server) setCache(c *fiber.Ctx) error {
 	key := c.Params("key")
 	value := c.FormValue("value")
-	err := s.cache.Set(key, value)
+	err := s.cache.Set(c.UserContext(), key, value)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: setCache] Redis error:", err)
+		return serverError(c, err, err.Error())
 	}
 	return c.SendString("Saved")
 }
@@ -2,11 +2,23 @@ package controller
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
 )
 
 func (s *Server) HealthCheck(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[API: HealthCheck]: Called")
+	s.logger.InfoWithID(c.UserContext(), "[API: HealthCheck]: Called")
 	response := s.healthCheckService.HealthCheck()
-	s.logger.InfoWithID(c.Context(), "[API: HealthCheck]: Response: %v", response)
+	s.logger.InfoWithID(c.UserContext(), "[API: HealthCheck]: Response: %v", response)
 	return c.Status(fiber.StatusOK).JSON(response)
-}
\ No newline at end of file
+}
+
+// Readiness answers 503 while a critical dependency is down so the load
+// balancer stops routing to this task.
+func (s *Server) Readiness(c *fiber.Ctx) error {
+	response := s.healthCheckService.Ready(c.UserContext())
+	if response.Status == entity.HealthStatusUnavailable {
+		s.logger.ErrorWithID(c.UserContext(), "[API: Readiness]: Not ready:", response.Checks)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
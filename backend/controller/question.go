@@ -1,45 +1,58 @@
 package controller
 
 import (
-	"strconv"
+	"errors"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
 )
 
 // CreateQuestion handles POST /question
 func (s *Server) CreateQuestion(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: CreateQuestion] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: CreateQuestion] Called")
 
 	var req entity.CreateQuestionRequest
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestion] Error parsing request body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestion] Error parsing request body:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: CreateQuestion] Request parsed for question:", req.QuestionText)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: CreateQuestion] Request parsed for question:", req.QuestionText)
 
 	// Parse the archive date
 	archiveDate, err := time.Parse("2006-01-02", req.ArchiveDate)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestion] Invalid archive_date format:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestion] Invalid archive_date format:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid archive_date format, use YYYY-MM-DD",
 		})
 	}
 
-	// Parse created_by as a UUID
-	createdByUUID, err := uuid.Parse(req.CreatedBy)
+	// The creator is always the authenticated user; created_by may only repeat it.
+	createdBy, err := s.requestUserID(c, "CreateQuestion", req.CreatedBy)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestion] Invalid created_by UUID:", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid created_by (expected a UUID)",
-		})
+		return identityError(c, err)
+	}
+	createdByUUID, err := uuid.Parse(createdBy)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestion] Invalid user ID in token:", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	options := make([]model.QuestionOption, len(req.Options))
+	for i, option := range req.Options {
+		options[i] = model.QuestionOption{OptionText: option.Text, VoteCount: option.Count}
 	}
 
 	question, err := s.questionService.CreateQuestion(
-		c.Context(),
+		c.UserContext(),
 		archiveDate,
 		req.QuestionText,
 		req.FirstChoice,
@@ -48,118 +61,259 @@ func (s *Server) CreateQuestion(c *fiber.Ctx) error {
 		req.FirstChoiceCount,
 		req.SecondChoiceCount,
 		createdByUUID,
+		options,
 	)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestion] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidOptions) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestion] Invalid options:", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: CreateQuestion] Question created successfully")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: CreateQuestion] Question created successfully")
 	return c.Status(fiber.StatusCreated).JSON(question)
 }
 
-// GetAllQuestions handles GET /question
-func (s *Server) GetAllQuestions(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetAllQuestions] Called")
+// ListQuestions handles GET /question
+func (s *Server) ListQuestions(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: ListQuestions] Called")
 
-	// Pass context to the service call if supported.
-	questions, err := s.questionService.GetAllQuestions(c.Context())
+	filter := repository.QuestionFilter{
+		From:            c.Query("from"),
+		To:              c.Query("to"),
+		MinParticipants: c.QueryInt("min_participants", 0),
+		Sort:            c.Query("sort", repository.QuestionSortDate),
+		Limit:           c.QueryInt("limit", 20),
+	}
+	if !repository.ValidQuestionSort(filter.Sort) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be one of date, participants or margin"})
+	}
+	switch c.Query("order", "desc") {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "order must be asc or desc"})
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and 100"})
+	}
+	if filter.MinParticipants < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "min_participants must not be negative"})
+	}
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from and to must be dates formatted YYYY-MM-DD"})
+		}
+	}
+	if raw := c.Query("created_by"); raw != "" {
+		createdBy, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "created_by must be a user ID"})
+		}
+		filter.CreatedBy = &createdBy
+	}
+
+	page, err := s.questionService.ListQuestions(c.UserContext(), filter, c.Query("cursor"))
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetAllQuestions] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: ListQuestions] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: GetAllQuestions] Retrieved questions successfully")
-	return c.Status(fiber.StatusOK).JSON(questions)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: ListQuestions] Retrieved questions successfully")
+	return c.Status(fiber.StatusOK).JSON(page)
 }
 
 // GetQuestion handles GET /question/:id
 func (s *Server) GetQuestion(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetQuestion] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetQuestion] Called")
 
 	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := uuid.Parse(idParam)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetQuestion] Invalid question ID:", idParam)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetQuestion] Invalid question ID:", idParam)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid question ID"})
 	}
 
-	q, err := s.questionService.GetQuestionByID(c.Context(), id)
+	q, err := s.questionService.GetQuestionByID(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "question not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: GetQuestion] Question not found with id:", id)
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: GetQuestion] Question not found with id:", id)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetQuestion] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: GetQuestion] Retrieved question with id:", id)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetQuestion] Retrieved question with id:", id)
 	return c.Status(fiber.StatusOK).JSON(q)
 }
 
 // DeleteQuestion handles DELETE /question/:id
 func (s *Server) DeleteQuestion(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: DeleteQuestion] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: DeleteQuestion] Called")
 
 	idParam := c.Params("id")
-	id, err := strconv.Atoi(idParam)
+	id, err := uuid.Parse(idParam)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: DeleteQuestion] Invalid question ID:", idParam)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestion] Invalid question ID:", idParam)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid question ID"})
 	}
 
-	if err := s.questionService.DeleteQuestion(c.Context(), id); err != nil {
-		if err.Error() == "question not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: DeleteQuestion] Question not found with id:", id)
+	actor, err := s.requestActor(c, "DeleteQuestion")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	if err := s.questionService.DeleteQuestion(c.UserContext(), actor, id); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestion] Forbidden for question id:", id)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestion] Question not found with id:", id)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: DeleteQuestion] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: DeleteQuestion] Question deleted successfully with id:", id)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: DeleteQuestion] Question deleted successfully with id:", id)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Question deleted successfully"})
 }
 
 // CreateQuestionCache handles POST /question/cache
 func (s *Server) CreateQuestionCache(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: CreateQuestionCache] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: CreateQuestionCache] Called")
 
 	var req entity.CreateQuestionCacheRequest
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestionCache] Failed to parse body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestionCache] Failed to parse body:", err)
+		if errors.Is(err, util.ErrMalformedMilestones) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
 	// ✅ Inject user ID from JWT context
-	userID, ok := c.Locals("userID").(string)
-	if !ok || userID == "" {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestionCache] Missing user ID in context")
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	userID, err := s.requestUserID(c, "CreateQuestionCache", req.UserID)
+	if err != nil {
+		return identityError(c, err)
 	}
 	req.UserID = userID
 
 	// ⛏ Call the service
-	id, err := s.questionService.CreateQuestionCache(c.Context(), req)
+	resp, err := s.questionService.CreateQuestionCache(c.UserContext(), req)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: CreateQuestionCache] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, service.ErrInvalidOptions) || errors.Is(err, service.ErrInvalidSchedule) || errors.Is(err, service.ErrInvalidTimezone) ||
+			errors.Is(err, service.ErrInvalidMilestones) || errors.Is(err, service.ErrUnknownFollowUp) || errors.Is(err, service.ErrFollowUpSchedule) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestionCache] Invalid request:", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: CreateQuestionCache] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: CreateQuestionCache] Successfully cached question with ID:", id)
+	message := "Question created successfully"
+	switch {
+	case resp.FollowUp:
+		message = "Follow-up question held until a milestone opens it"
+	case resp.Scheduled:
+		message = "Question scheduled successfully"
+	}
+	s.logger.InfoWithID(c.UserContext(), "[Controller: CreateQuestionCache] Successfully cached question with ID:", resp.QuestionID)
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message":     "Question created successfully",
-		"question_id": id,
+		"message":     message,
+		"question_id": resp.QuestionID,
+		"opens_at":    resp.OpensAt,
+		"closes_at":   resp.ClosesAt,
+		"scheduled":   resp.Scheduled,
+		"follow_up":   resp.FollowUp,
 	})
 }
 
+// SearchQuestions handles GET /question/search?q=
+func (s *Server) SearchQuestions(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: SearchQuestions] Called")
+
+	userID, err := s.requestUserID(c, "SearchQuestions", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || utf8.RuneCountInString(query) > 200 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q must be between 1 and 200 characters"})
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 50 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and 50"})
+	}
+
+	result, err := s.questionService.SearchQuestions(c.UserContext(), query, userID, limit)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: SearchQuestions] Service error:", err)
+		return serverError(c, err, err.Error())
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// GetScheduledQuestions handles GET /question/scheduled
+func (s *Server) GetScheduledQuestions(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetScheduledQuestions] Called")
+
+	userID, err := s.requestUserID(c, "GetScheduledQuestions", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	questions, err := s.questionService.ListScheduledQuestions(c.UserContext(), userID)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetScheduledQuestions] Service error:", err)
+		return serverError(c, err, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"questions": questions})
+}
+
 // GetQuestionCache handles GET /question/cache/:id
 func (s *Server) GetQuestionCache(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetQuestionCache] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetQuestionCache] Called")
+
+	userID, err := s.requestUserID(c, "GetQuestionCache", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	questionID := c.Params("id")
+	result, err := s.questionService.GetQuestionCache(c.UserContext(), questionID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: GetQuestionCache] Question not found:", questionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetQuestionCache] Service error:", err)
+		return serverError(c, err, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// GetFollowUps handles GET /question/cache/:id/follow-ups
+func (s *Server) GetFollowUps(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetFollowUps] Called")
 
 	questionID := c.Params("id")
-	result, err := s.questionService.GetQuestionCache(c.Context(), questionID)
+	result, err := s.questionService.GetFollowUps(c.UserContext(), questionID)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetQuestionCache] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: GetFollowUps] Question not found:", questionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetFollowUps] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(result)
@@ -167,12 +321,25 @@ func (s *Server) GetQuestionCache(c *fiber.Ctx) error {
 
 // DeleteQuestionCache handles DELETE /question/cache/:id
 func (s *Server) DeleteQuestionCache(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: DeleteQuestionCache] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: DeleteQuestionCache] Called")
+
+	actor, err := s.requestActor(c, "DeleteQuestionCache")
+	if err != nil {
+		return identityError(c, err)
+	}
 
 	questionID := c.Params("id")
-	if err := s.questionService.DeleteQuestionCache(c.Context(), questionID); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: DeleteQuestionCache] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := s.questionService.DeleteQuestionCache(c.UserContext(), actor, questionID); err != nil {
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestionCache] Question not found:", questionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestionCache] Forbidden for question id:", questionID)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteQuestionCache] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Deleted from cache"})
@@ -180,12 +347,17 @@ func (s *Server) DeleteQuestionCache(c *fiber.Ctx) error {
 
 // GetAllTodayQuestionIDs handles GET /question/cache/today
 func (s *Server) GetAllTodayQuestionIDs(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetAllTodayQuestionIDs] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetAllTodayQuestionIDs] Called")
+
+	userID, err := s.requestUserID(c, "GetAllTodayQuestionIDs", "")
+	if err != nil {
+		return identityError(c, err)
+	}
 
-	questions, err := s.questionService.GetAllTodayQuestions(c.Context())
+	questions, err := s.questionService.GetAllTodayQuestions(c.UserContext(), userID)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetAllTodayQuestionIDs] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetAllTodayQuestionIDs] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"questions": questions})
@@ -193,33 +365,80 @@ func (s *Server) GetAllTodayQuestionIDs(c *fiber.Ctx) error {
 
 // VoteForQuestion handles POST /question/vote
 func (s *Server) VoteForQuestion(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: VoteForQuestion] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: VoteForQuestion] Called")
 
-	// ✅ Inject user ID from JWT context
 	var req entity.VoteRequest
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: VoteForQuestion] Failed to parse body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: VoteForQuestion] Failed to parse body:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	resp, err := s.questionService.VoteForQuestion(c.Context(), req)
+	// ✅ Inject user ID from JWT context
+	userID, err := s.requestUserID(c, "VoteForQuestion", req.UserID)
+	if err != nil {
+		return identityError(c, err)
+	}
+	req.UserID = userID
+
+	resp, err := s.questionService.VoteForQuestion(c.UserContext(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: VoteForQuestion] Question not found:", req.QuestionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		}
+		if errors.Is(err, service.ErrUnknownOption) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: VoteForQuestion] Unknown option:", req.OptionID)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, service.ErrPollClosed) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: VoteForQuestion] Poll is not open:", req.QuestionID)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: VoteForQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RetractVote handles DELETE /question/vote/:id
+func (s *Server) RetractVote(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: RetractVote] Called")
+
+	userID, err := s.requestUserID(c, "RetractVote", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	questionID := c.Params("id")
+	resp, err := s.questionService.RetractVote(c.UserContext(), userID, questionID)
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: VoteForQuestion] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: RetractVote] Question not found:", questionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		case errors.Is(err, service.ErrNoVote):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrPollClosed), errors.Is(err, service.ErrVotesLocked):
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: RetractVote] Vote cannot be retracted:", err)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: RetractVote] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
 }
 
 func (s *Server) GetLastArchivedQuestion(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetLastArchivedQuestion] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetLastArchivedQuestion] Called")
 
-	q, err := s.questionService.GetLastArchivedQuestion(c.Context())
+	q, err := s.questionService.GetLastArchivedQuestion(c.UserContext())
 	if err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetLastArchivedQuestion] Service error:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetLastArchivedQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: GetLastArchivedQuestion] Successfully retrieved question with id:", q.QuestionID)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetLastArchivedQuestion] Successfully retrieved question with id:", q.QuestionID)
 	return c.Status(fiber.StatusOK).JSON(q)
 }
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuestionTestApp(repo *testfakes.QuestionRepo, userID, role string) *fiber.App {
+	logger := log.Initialize("test")
+	s := &Server{
+		app:             fiber.New(),
+		logger:          logger,
+		questionService: service.NewQuestionService(repo, nil, nil, nil, logger, nil, nil),
+	}
+	s.app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", userID)
+		c.Locals("role", role)
+		return c.Next()
+	})
+	s.app.Get("/question/:id", s.GetQuestion)
+	s.app.Delete("/question/:id", s.DeleteQuestion)
+	return s.app
+}
+
+func TestQuestionUUIDRoundTrip(t *testing.T) {
+	owner := uuid.New()
+	question := model.Question{
+		QuestionID:   uuid.New(),
+		ArchiveDate:  time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC),
+		QuestionText: "Tabs or spaces?",
+		FirstChoice:  "Tabs",
+		SecondChoice: "Spaces",
+		CreatedBy:    owner,
+	}
+	repo := testfakes.NewQuestionRepo(question)
+	app := newQuestionTestApp(repo, owner.String(), constant.RoleUser)
+	path := "/question/" + question.QuestionID.String()
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var got model.Question
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Equal(t, question.QuestionID, got.QuestionID)
+	require.Equal(t, question.QuestionText, got.QuestionText)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodDelete, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Empty(t, repo.Questions)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodDelete, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestQuestionInvalidID(t *testing.T) {
+	repo := testfakes.NewQuestionRepo()
+	app := newQuestionTestApp(repo, uuid.NewString(), constant.RoleAdmin)
+
+	for _, method := range []string{fiber.MethodGet, fiber.MethodDelete} {
+		resp, err := app.Test(httptest.NewRequest(method, "/question/42", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusBadRequest, resp.StatusCode, method)
+	}
+}
+
+func TestDeleteQuestionRequiresOwnerOrModerator(t *testing.T) {
+	question := model.Question{QuestionID: uuid.New(), CreatedBy: uuid.New()}
+	repo := testfakes.NewQuestionRepo(question)
+	path := "/question/" + question.QuestionID.String()
+
+	resp, err := newQuestionTestApp(repo, uuid.NewString(), constant.RoleUser).Test(httptest.NewRequest(fiber.MethodDelete, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	require.Len(t, repo.Questions, 1)
+
+	resp, err = newQuestionTestApp(repo, uuid.NewString(), constant.RoleModerator).Test(httptest.NewRequest(fiber.MethodDelete, path, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Empty(t, repo.Questions)
+}
@@ -1,12 +1,8 @@
 package controller
 
 import (
-	"crypto/tls"
-	"net/http"
+	"context"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors" // <--- import the cors middleware
 	"github.com/guncv/Poll-Voting-Website/backend/config"
@@ -15,6 +11,7 @@ import (
 	"github.com/guncv/Poll-Voting-Website/backend/log"
 	"github.com/guncv/Poll-Voting-Website/backend/repository"
 	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
 	"gorm.io/gorm"
 )
 
@@ -28,52 +25,116 @@ type Server struct {
 	healthCheckService service.HealthCheckService
 	userService        service.UserService
 	questionService    service.IQuestionService
+	tokenService       service.ITokenService
+	outboxService      service.IOutboxService
+	schedulerService   service.ISchedulerService
+	jwt                *util.JWTManager
 }
 
-func NewNotificationClient(cfg config.NotificationConfig, log log.LoggerInterface) *sns.Client {
-	customCreds := aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
-		cfg.AccessKey,
-		cfg.SecretKey,
-		cfg.SessionToken,
-	))
-
-	// Set custom HTTP client to skip SSL certificate verification
-	customHTTPClient := &http.Client{
-		Timeout: constant.HTTPTimeout,
-		Transport: &http.Transport{
-			// Use default TLS settings (including CA certificates)
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false, // Set to false to enable certificate verification
-			},
-		},
-	}
+// ServerOption replaces a dependency NewServer would otherwise build from the
+// database and config, e.g. to run the server against in-memory stand-ins.
+type ServerOption func(*serverDeps)
+
+type serverDeps struct {
+	logger           log.LoggerInterface
+	healthCheckRepo  repository.HealthCheckRepository
+	notificationRepo repository.INotificationRepository
+	outboxRepo       repository.IOutboxRepository
+	userRepo         repository.UserRepository
+	questionRepo     repository.QuestionRepository
+	scheduledRepo    repository.ScheduledQuestionRepository
+	voteRepo         repository.VoteRepository
+}
+
+func WithLogger(logger log.LoggerInterface) ServerOption {
+	return func(d *serverDeps) { d.logger = logger }
+}
 
-	// Return a new SNS client with the custom HTTP client
-	return sns.New(sns.Options{
-		Credentials: customCreds,
-		Region:      constant.SNSRegion, // Replace with your desired region
-		HTTPClient:  customHTTPClient,   // Use the custom HTTP client
-	})
+func WithHealthCheckRepository(r repository.HealthCheckRepository) ServerOption {
+	return func(d *serverDeps) { d.healthCheckRepo = r }
 }
 
-// NewServer creates a new Fiber server with injected dependencies.
-func NewServer(cfg config.Config, db *gorm.DB, cacheService db.CacheService) *Server {
-	logger := log.Initialize(cfg.AppEnv)
-	healthService := service.NewHealthCheckService()
+// WithNotificationRepository replaces the channels named in NOTIFICATION_CHANNELS.
+func WithNotificationRepository(r repository.INotificationRepository) ServerOption {
+	return func(d *serverDeps) { d.notificationRepo = r }
+}
+
+func WithOutboxRepository(r repository.IOutboxRepository) ServerOption {
+	return func(d *serverDeps) { d.outboxRepo = r }
+}
+
+func WithUserRepository(r repository.UserRepository) ServerOption {
+	return func(d *serverDeps) { d.userRepo = r }
+}
+
+func WithQuestionRepository(r repository.QuestionRepository) ServerOption {
+	return func(d *serverDeps) { d.questionRepo = r }
+}
+
+func WithScheduledQuestionRepository(r repository.ScheduledQuestionRepository) ServerOption {
+	return func(d *serverDeps) { d.scheduledRepo = r }
+}
+
+func WithVoteRepository(r repository.VoteRepository) ServerOption {
+	return func(d *serverDeps) { d.voteRepo = r }
+}
+
+// NewServer creates a new Fiber server with injected dependencies. Those not
+// given through opts are built on db and cfg.
+func NewServer(cfg config.Config, db *gorm.DB, cacheService db.CacheService, opts ...ServerOption) *Server {
+	var deps serverDeps
+	for _, opt := range opts {
+		opt(&deps)
+	}
+	if deps.logger == nil {
+		deps.logger = log.Initialize(cfg.AppEnv)
+	}
+	logger := deps.logger
 
 	// Notification
-	notificationClient := NewNotificationClient(cfg.Notification, logger)
-	notificationRepo := repository.NewNotificationRepository(notificationClient, cfg, logger)
-	notificationService := service.NewNotificationService(notificationRepo, logger)
+	if deps.notificationRepo == nil {
+		notificationRepo, err := repository.NewNotificationRepository(cfg, logger)
+		if err != nil {
+			panic("❌ Failed to configure notifications: " + err.Error())
+		}
+		deps.notificationRepo = notificationRepo
+	}
+	if deps.outboxRepo == nil {
+		deps.outboxRepo = repository.NewOutboxRepository(db, logger)
+	}
+	notificationService := service.NewNotificationService(deps.notificationRepo, deps.outboxRepo, logger)
+	outboxService := service.NewOutboxService(deps.outboxRepo, notificationService, logger)
+
+	// Health
+	if deps.healthCheckRepo == nil {
+		deps.healthCheckRepo = repository.NewHealthCheckRepository(db, logger)
+	}
+	healthService := service.NewHealthCheckService(deps.healthCheckRepo, cacheService, deps.outboxRepo, logger)
 
 	// User
-	userRepo := repository.NewUserRepository(db, logger)
-	userService := service.NewUserService(userRepo, logger, notificationService)
+	if deps.userRepo == nil {
+		deps.userRepo = repository.NewUserRepository(db, logger)
+	}
+	userService := service.NewUserService(deps.userRepo, logger)
+	jwtManager, err := util.NewJWTManager(cfg.JWT)
+	if err != nil {
+		panic("❌ Failed to load JWT signing keys: " + err.Error())
+	}
+	tokenService := service.NewTokenService(cacheService, jwtManager, logger)
 
 	// Question
-	questionRepo := repository.NewQuestionRepository(db, logger)
+	if deps.questionRepo == nil {
+		deps.questionRepo = repository.NewQuestionRepository(db, logger)
+	}
+	if deps.scheduledRepo == nil {
+		deps.scheduledRepo = repository.NewScheduledQuestionRepository(db, logger)
+	}
+	if deps.voteRepo == nil {
+		deps.voteRepo = repository.NewVoteRepository(db, logger)
+	}
 	// IMPORTANT: pass cacheService to the question service here
-	questionService := service.NewQuestionService(questionRepo, cacheService, logger, userService, notificationService)
+	questionService := service.NewQuestionService(deps.questionRepo, deps.scheduledRepo, deps.voteRepo, cacheService, logger, userService, notificationService)
+	schedulerService := service.NewSchedulerService(questionService, logger)
 
 	// Create Fiber instance
 	app := fiber.New()
@@ -94,6 +155,10 @@ func NewServer(cfg config.Config, db *gorm.DB, cacheService db.CacheService) *Se
 		healthCheckService: healthService,
 		userService:        userService,
 		questionService:    questionService,
+		tokenService:       tokenService,
+		outboxService:      outboxService,
+		schedulerService:   schedulerService,
+		jwt:                jwtManager,
 	}
 
 	// Set up routes on the fiber app
@@ -104,8 +169,14 @@ func NewServer(cfg config.Config, db *gorm.DB, cacheService db.CacheService) *Se
 
 // setupRoutes defines all routes for the application.
 func (s *Server) setupRoutes() {
+	s.app.Use(s.TimeoutMiddleware)
+
+	s.app.Get("/.well-known/jwks.json", s.JWKS)
+
 	api := s.app.Group("/api")
 	api.Get("/health", s.HealthCheck)
+	api.Get("/health/live", s.HealthCheck)
+	api.Get("/health/ready", s.Readiness)
 
 	// ========================================
 	// User routes
@@ -113,12 +184,16 @@ func (s *Server) setupRoutes() {
 	user := api.Group("/user")
 	user.Post("/register", s.Register)
 	user.Post("/login", s.Login)
-	user.Get("/logout", s.Logout)
+	user.Post("/refresh", s.Refresh)
+	user.Post("/logout", s.Logout)
+
+	user.Use(s.JWTMiddleware)
 
-	user.Use(JWTMiddleware)
+	user.Post("/logout/all", s.LogoutAll)
 
 	// Static
 	user.Get("/profile", s.Profile)
+	user.Get("/me/votes", s.MyVotes)
 	// Dynamic
 	user.Get("/:id", s.GetUser)
 	user.Delete("/:id", s.DeleteUser)
@@ -127,24 +202,30 @@ func (s *Server) setupRoutes() {
 	// ========================================
 	// Question routes
 	// ========================================
+	// Live tallies; registered ahead of the group so browsers can pass a
+	// stream ticket as a query parameter.
+	api.Get("/question/:id/stream", s.StreamTicketMiddleware, s.StreamQuestion)
+
 	q := api.Group("/question")
-	q.Use(JWTMiddleware)
+	q.Use(s.JWTMiddleware)
 
 	// General question routes
-	q.Post("/", s.CreateQuestion)
-	q.Get("/", s.GetAllQuestions)
+	// Archived questions are normally written by the archiver; manual inserts are admin-only.
+	q.Post("/", s.RequireRole(constant.RoleAdmin), s.CreateQuestion)
+	q.Get("/", s.ListQuestions)
 
 	// Specific routes
-	// General question routes
-	q.Post("/", s.CreateQuestion)
-	q.Get("/", s.GetAllQuestions)
 	q.Post("/vote", s.VoteForQuestion)
+	q.Delete("/vote/:id", s.RetractVote)
 
 	// Specific routes
 	q.Get("/last", s.GetLastArchivedQuestion)
+	q.Get("/scheduled", s.GetScheduledQuestions)
+	q.Get("/search", s.SearchQuestions)
 
 	// Parameterized routes
 	q.Get("/:id", s.GetQuestion)
+	q.Post("/:id/stream/ticket", s.IssueStreamTicket)
 	q.Delete("/:id", s.DeleteQuestion)
 
 	// Cache routes
@@ -152,17 +233,41 @@ func (s *Server) setupRoutes() {
 	c.Post("/", s.CreateQuestionCache)
 	c.Get("/today", s.GetAllTodayQuestionIDs)
 	c.Get("/:id", s.GetQuestionCache)
+	c.Get("/:id/follow-ups", s.GetFollowUps)
 	c.Delete("/:id", s.DeleteQuestionCache)
 
+	// ========================================
+	// Admin routes
+	// ========================================
+	admin := api.Group("/admin", s.JWTMiddleware, s.RequireRole(constant.RoleAdmin))
+	admin.Put("/users/:id/role", s.GrantRole)
+	admin.Delete("/users/:id/role", s.RevokeRole)
+	admin.Get("/notifications/dead", s.ListDeadNotifications)
+	admin.Post("/notifications/:id/retry", s.RetryDeadNotification)
+
 	// ========================================
 	// Cache test routes
 	// ========================================
-	cache := api.Group("/cache")
+	cache := api.Group("/cache", s.JWTMiddleware, s.RequireRole(constant.RoleAdmin))
 	cache.Get("/:key", s.getCache)
 	cache.Post("/:key", s.setCache)
 }
 
+// TimeoutMiddleware bounds the context handlers pass to services by
+// constant.RequestTimeout. Live streams outlive the handler and run on their
+// own context instead.
+func (s *Server) TimeoutMiddleware(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), constant.RequestTimeout)
+	defer cancel()
+	c.SetUserContext(ctx)
+	return c.Next()
+}
+
 // Start runs the Fiber app.
 func (s *Server) Start(address string) error {
+	// Deliver queued notifications and open scheduled polls for as long as
+	// the server runs.
+	go s.outboxService.Start(context.Background())
+	go s.schedulerService.Start(context.Background())
 	return s.app.Listen(address)
 }
@@ -0,0 +1,500 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a Server wired to in-memory stand-ins for Postgres, Redis
+// and SNS.
+type testServer struct {
+	t      *testing.T
+	server *Server
+	users  *testfakes.UserRepo
+	outbox *testfakes.OutboxRepo
+	health *testfakes.HealthCheckRepo
+}
+
+func newTestServer(t *testing.T) *testServer {
+	cfg := config.Config{
+		AppEnv:        "test",
+		CorsECSDomain: "http://localhost:3000",
+		JWT:           config.JWTConfig{Algorithm: "HS256", ActiveKeyID: "test", Secrets: "test:e2e-signing-secret"},
+	}
+	users := testfakes.NewUserRepo()
+	outbox := &testfakes.OutboxRepo{}
+	health := &testfakes.HealthCheckRepo{}
+	server := NewServer(cfg, nil, db.NewMemoryCacheService(),
+		WithLogger(log.Initialize("test")),
+		WithHealthCheckRepository(health),
+		WithNotificationRepository(&testfakes.NotificationRepo{}),
+		WithOutboxRepository(outbox),
+		WithUserRepository(users),
+		WithQuestionRepository(testfakes.NewQuestionRepo()),
+		WithScheduledQuestionRepository(testfakes.NewScheduledRepo()),
+		WithVoteRepository(testfakes.NewVoteRepo()),
+	)
+	return &testServer{t: t, server: server, users: users, outbox: outbox, health: health}
+}
+
+// do sends a request through app.Test and decodes a JSON response into out
+// when out is not nil. headers are name/value pairs.
+func (ts *testServer) do(method, path string, body interface{}, out interface{}, headers ...string) *http.Response {
+	ts.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		require.NoError(ts.t, err)
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := ts.server.app.Test(req, -1)
+	require.NoError(ts.t, err)
+	if out != nil {
+		require.NoError(ts.t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+func bearer(token string) []string {
+	return []string{fiber.HeaderAuthorization, "Bearer " + token}
+}
+
+// refreshCookie returns the refresh_token cookie set by resp as a Cookie
+// header value.
+func refreshCookie(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "refresh_token" {
+			return cookie.Name + "=" + cookie.Value
+		}
+	}
+	t.Fatal("no refresh_token cookie in response")
+	return ""
+}
+
+func TestPollLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	credentials := fiber.Map{"email": "voter@example.com", "password": "correct horse"}
+
+	// Register, once.
+	var registered struct {
+		UserID string `json:"user_id"`
+	}
+	resp := ts.do(fiber.MethodPost, "/api/user/register", credentials, &registered)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, registered.UserID)
+	require.Len(t, ts.users.Outbox, 1)
+	require.Equal(t, model.OutboxKindSubscribeUser, ts.users.Outbox[0].Kind)
+
+	resp = ts.do(fiber.MethodPost, "/api/user/register", credentials, nil)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	// Log in.
+	resp = ts.do(fiber.MethodPost, "/api/user/login", fiber.Map{"email": "voter@example.com", "password": "wrong"}, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	resp = ts.do(fiber.MethodPost, "/api/user/login", credentials, &login)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, login.AccessToken)
+	cookie := refreshCookie(t, resp)
+	auth := bearer(login.AccessToken)
+
+	resp = ts.do(fiber.MethodPost, "/api/question/cache/", fiber.Map{"text": "Tea or coffee?", "options": []string{"Tea", "Coffee"}}, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// Create a poll.
+	var created entity.CreateQuestionCacheResponse
+	resp = ts.do(fiber.MethodPost, "/api/question/cache/", fiber.Map{"text": "Tea or coffee?", "options": []string{"Tea", "Coffee"}}, &created, auth...)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, created.QuestionID)
+	require.False(t, created.Scheduled)
+
+	var poll model.QuestionCache
+	resp = ts.do(fiber.MethodGet, "/api/question/cache/"+created.QuestionID, nil, &poll, auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Len(t, poll.Options, 2)
+	require.Empty(t, poll.MyVote)
+	coffee := poll.Options[1]
+
+	// Vote, twice.
+	var vote entity.VoteResponse
+	resp = ts.do(fiber.MethodPost, "/api/question/vote", fiber.Map{"question_id": created.QuestionID, "option_id": coffee.OptionID}, &vote, auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, vote.TotalParticipants)
+	require.Equal(t, 1, vote.Options[1].Count)
+
+	resp = ts.do(fiber.MethodPost, "/api/question/vote", fiber.Map{"question_id": created.QuestionID, "option_id": coffee.OptionID}, &vote, auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.True(t, vote.AlreadyVoted)
+
+	// Fetch the tallies.
+	resp = ts.do(fiber.MethodGet, "/api/question/cache/"+created.QuestionID, nil, &poll, auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, poll.TotalParticipants)
+	require.Equal(t, 0, poll.Options[0].Count)
+	require.Equal(t, 1, poll.Options[1].Count)
+	require.Equal(t, coffee.OptionID, poll.MyVote)
+
+	var history entity.VoteHistory
+	resp = ts.do(fiber.MethodGet, "/api/user/me/votes", nil, &history, auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Len(t, history.Votes, 1)
+	require.Equal(t, "Coffee", history.Votes[0].OptionText)
+
+	// Log out; the refresh token stops working.
+	resp = ts.do(fiber.MethodPost, "/api/user/logout", nil, nil, fiber.HeaderCookie, cookie)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get(fiber.HeaderSetCookie), "refresh_token=;"))
+
+	resp = ts.do(fiber.MethodPost, "/api/user/refresh", nil, nil, fiber.HeaderCookie, cookie)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+// session is a signed-in user of a testServer.
+type session struct {
+	userID string
+	auth   []string // Authorization header pair
+	cookie string   // refresh_token Cookie header value
+}
+
+// signUp registers email, gives it role and logs in.
+func (ts *testServer) signUp(email, role string) session {
+	ts.t.Helper()
+	credentials := fiber.Map{"email": email, "password": "correct horse"}
+	var registered struct {
+		UserID string `json:"user_id"`
+	}
+	resp := ts.do(fiber.MethodPost, "/api/user/register", credentials, &registered)
+	require.Equal(ts.t, fiber.StatusCreated, resp.StatusCode)
+	u := ts.users.Users[registered.UserID]
+	u.Role = role
+	ts.users.Users[registered.UserID] = u
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	resp = ts.do(fiber.MethodPost, "/api/user/login", credentials, &login)
+	require.Equal(ts.t, fiber.StatusOK, resp.StatusCode)
+	return session{userID: registered.UserID, auth: bearer(login.AccessToken), cookie: refreshCookie(ts.t, resp)}
+}
+
+// refresh posts cookie to /api/user/refresh.
+func (ts *testServer) refresh(cookie string) *http.Response {
+	ts.t.Helper()
+	return ts.do(fiber.MethodPost, "/api/user/refresh", nil, nil, fiber.HeaderCookie, cookie)
+}
+
+func TestRefresh(t *testing.T) {
+	ts := newTestServer(t)
+	cookie := ts.signUp("tabs@example.com", constant.RoleUser).cookie
+
+	// Refresh and logout change session state, so a GET falls through to the
+	// routes that need an access token instead.
+	for _, path := range []string{"/api/user/refresh", "/api/user/logout"} {
+		resp := ts.do(fiber.MethodGet, path, nil, nil, fiber.HeaderCookie, cookie)
+		require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode, path)
+		require.Empty(t, resp.Header.Get(fiber.HeaderSetCookie), path)
+	}
+
+	// Two tabs refresh with the same cookie at once; both stay signed in.
+	resp := ts.refresh(cookie)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	first := refreshCookie(t, resp)
+	resp = ts.refresh(cookie)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	second := refreshCookie(t, resp)
+	require.NotEqual(t, first, second)
+	require.Equal(t, fiber.StatusOK, ts.refresh(first).StatusCode)
+
+	// Logging out closes the grace window too.
+	resp = ts.do(fiber.MethodPost, "/api/user/logout", nil, nil, fiber.HeaderCookie, second)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(cookie).StatusCode)
+}
+
+func TestRefreshGraceWindowIsSingleUse(t *testing.T) {
+	ts := newTestServer(t)
+	stolen := ts.signUp("victim@example.com", constant.RoleUser).cookie
+
+	resp := ts.refresh(stolen)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	current := refreshCookie(t, resp)
+
+	// The first replay inside the window is let through, the second is
+	// reuse and ends the session.
+	resp = ts.refresh(stolen)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	replayed := refreshCookie(t, resp)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(stolen).StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(current).StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(replayed).StatusCode)
+}
+
+func TestAccountChangesEndSessions(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.signUp("owner@example.com", constant.RoleUser)
+	other := ts.signUp("laptop@example.com", constant.RoleUser)
+	resp := ts.do(fiber.MethodPost, "/api/user/login", fiber.Map{"email": "owner@example.com", "password": "correct horse"}, nil)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	laptop := refreshCookie(t, resp)
+
+	// Changing the email keeps sessions; changing the password ends them all.
+	resp = ts.do(fiber.MethodPut, "/api/user/"+owner.userID, fiber.Map{"email": "owner2@example.com"}, nil, owner.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	resp = ts.refresh(laptop)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	laptop = refreshCookie(t, resp)
+
+	resp = ts.do(fiber.MethodPut, "/api/user/"+owner.userID, fiber.Map{"password": "battery staple"}, nil, owner.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(owner.cookie).StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(laptop).StatusCode)
+
+	// Deleting an account ends its sessions.
+	resp = ts.do(fiber.MethodDelete, "/api/user/"+other.userID, nil, nil, other.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.StatusUnauthorized, ts.refresh(other.cookie).StatusCode)
+}
+
+// accessRole returns the role claim of the access token in resp.
+func (ts *testServer) accessRole(resp *http.Response) string {
+	ts.t.Helper()
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(ts.t, json.NewDecoder(resp.Body).Decode(&body))
+	token, err := ts.server.jwt.ValidateAccessToken(body.AccessToken)
+	require.NoError(ts.t, err)
+	role, _ := token.Claims.(jwt.MapClaims)["role"].(string)
+	return role
+}
+
+func TestRoles(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.signUp("admin@example.com", constant.RoleAdmin)
+	moderator := ts.signUp("moderator@example.com", constant.RoleModerator)
+	user := ts.signUp("user@example.com", constant.RoleUser)
+
+	// Admin and cache routes are closed to everyone but admins.
+	adminOnly := []struct{ method, path string }{
+		{fiber.MethodPut, "/api/admin/users/" + moderator.userID + "/role"},
+		{fiber.MethodDelete, "/api/admin/users/" + moderator.userID + "/role"},
+		{fiber.MethodGet, "/api/admin/notifications/dead"},
+		{fiber.MethodPost, "/api/admin/notifications/" + uuid.NewString() + "/retry"},
+		{fiber.MethodGet, "/api/cache/some-key"},
+		{fiber.MethodPost, "/api/cache/some-key"},
+	}
+	for _, route := range adminOnly {
+		for _, caller := range []session{user, moderator} {
+			resp := ts.do(route.method, route.path, fiber.Map{"role": constant.RoleAdmin}, nil, caller.auth...)
+			require.Equal(t, fiber.StatusForbidden, resp.StatusCode, route.method+" "+route.path)
+		}
+	}
+	require.Equal(t, constant.RoleModerator, ts.users.Users[moderator.userID].Role)
+	resp := ts.do(fiber.MethodGet, "/api/admin/notifications/dead", nil, nil, admin.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// Admins cannot change their own role.
+	resp = ts.do(fiber.MethodPut, "/api/admin/users/"+admin.userID+"/role", fiber.Map{"role": constant.RoleUser}, nil, admin.auth...)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	resp = ts.do(fiber.MethodDelete, "/api/admin/users/"+admin.userID+"/role", nil, nil, admin.auth...)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	require.Equal(t, constant.RoleAdmin, ts.users.Users[admin.userID].Role)
+
+	// Role changes reach the access token on the next refresh.
+	resp = ts.do(fiber.MethodDelete, "/api/admin/users/"+moderator.userID+"/role", nil, nil, admin.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	resp = ts.refresh(moderator.cookie)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, constant.RoleUser, ts.accessRole(resp))
+
+	resp = ts.do(fiber.MethodPut, "/api/admin/users/"+user.userID+"/role", fiber.Map{"role": constant.RoleAdmin}, nil, admin.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	resp = ts.refresh(user.cookie)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, constant.RoleAdmin, ts.accessRole(resp))
+}
+
+func TestJWTMiddlewareRequiresSubject(t *testing.T) {
+	ts := newTestServer(t)
+
+	token, err := ts.server.jwt.GenerateAccessToken("", constant.RoleAdmin)
+	require.NoError(t, err)
+	resp := ts.do(fiber.MethodGet, "/api/user/me/votes", nil, nil, bearer(token)...)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	resp = ts.do(fiber.MethodGet, "/api/admin/notifications/dead", nil, nil, bearer(token)...)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestImpersonationRejected(t *testing.T) {
+	ts := newTestServer(t)
+	victim := ts.signUp("victim@example.com", constant.RoleUser)
+	attacker := ts.signUp("attacker@example.com", constant.RoleAdmin)
+
+	var created entity.CreateQuestionCacheResponse
+	resp := ts.do(fiber.MethodPost, "/api/question/cache/", fiber.Map{"text": "Cats or dogs?", "options": []string{"Cats", "Dogs"}}, &created, victim.auth...)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var poll model.QuestionCache
+	resp = ts.do(fiber.MethodGet, "/api/question/cache/"+created.QuestionID, nil, &poll, victim.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	// A vote cast in the victim's name is refused and not counted.
+	resp = ts.do(fiber.MethodPost, "/api/question/vote", fiber.Map{"user_id": victim.userID, "question_id": created.QuestionID, "option_id": poll.Options[0].OptionID}, nil, attacker.auth...)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp = ts.do(fiber.MethodGet, "/api/question/cache/"+created.QuestionID, nil, &poll, victim.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 0, poll.TotalParticipants)
+	require.Equal(t, 0, poll.Options[0].Count)
+	require.Empty(t, poll.MyVote)
+
+	// Polls cannot be created in someone else's name either.
+	resp = ts.do(fiber.MethodPost, "/api/question/cache/", fiber.Map{"user_id": victim.userID, "text": "Tea or coffee?", "options": []string{"Tea", "Coffee"}}, nil, attacker.auth...)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	resp = ts.do(fiber.MethodPost, "/api/question/", fiber.Map{"created_by": victim.userID, "archive_date": "2024-01-01", "question_text": "Tea or coffee?", "first_choice": "Tea", "second_choice": "Coffee"}, nil, attacker.auth...)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// Naming yourself is fine.
+	resp = ts.do(fiber.MethodPost, "/api/question/vote", fiber.Map{"user_id": attacker.userID, "question_id": created.QuestionID, "option_id": poll.Options[0].OptionID}, nil, attacker.auth...)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestRequestDeadline(t *testing.T) {
+	ts := newTestServer(t)
+	caller := ts.signUp("deadline@example.com", constant.RoleUser)
+
+	var remaining time.Duration
+	var userID interface{}
+	ts.server.app.Get("/api/deadline", ts.server.JWTMiddleware, func(c *fiber.Ctx) error {
+		deadline, ok := c.UserContext().Deadline()
+		require.True(t, ok)
+		remaining = time.Until(deadline)
+		userID = c.UserContext().Value("userID")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp := ts.do(fiber.MethodGet, "/api/deadline", nil, nil, caller.auth...)
+	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	require.Positive(t, remaining)
+	require.LessOrEqual(t, remaining, constant.RequestTimeout)
+	require.Equal(t, caller.userID, userID)
+}
+
+func TestStreamTicket(t *testing.T) {
+	ts := newTestServer(t)
+	viewer := ts.signUp("viewer@example.com", constant.RoleUser)
+	// No poll has this ID, so a stream that gets past authentication answers
+	// 404 instead of staying open.
+	questionID := uuid.NewString()
+	stream := "/api/question/" + questionID + "/stream"
+	ticketPath := stream + "/ticket"
+
+	// Access tokens are no longer accepted in the query string.
+	resp := ts.do(fiber.MethodGet, stream+"?access_token="+strings.TrimPrefix(viewer.auth[1], "Bearer "), nil, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = ts.do(fiber.MethodPost, ticketPath, nil, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	var issued entity.StreamTicketResponse
+	resp = ts.do(fiber.MethodPost, ticketPath, nil, &issued, viewer.auth...)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, issued.Ticket)
+	require.Equal(t, int(constant.StreamTicketTTL.Seconds()), issued.ExpiresIn)
+
+	// A ticket opens the stream once.
+	resp = ts.do(fiber.MethodGet, stream+"?ticket="+issued.Ticket, nil, nil)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	resp = ts.do(fiber.MethodGet, stream+"?ticket="+issued.Ticket, nil, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// Tickets are bound to the question they were issued for.
+	resp = ts.do(fiber.MethodPost, ticketPath, nil, &issued, viewer.auth...)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	resp = ts.do(fiber.MethodGet, "/api/question/"+uuid.NewString()+"/stream?ticket="+issued.Ticket, nil, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = ts.do(fiber.MethodGet, stream+"?ticket=made-up", nil, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// Clients that can set headers still use their access token.
+	resp = ts.do(fiber.MethodGet, stream, nil, nil, viewer.auth...)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestHealthChecks(t *testing.T) {
+	ts := newTestServer(t)
+
+	var live entity.HealthCheckResponse
+	resp := ts.do(fiber.MethodGet, "/api/health/live", nil, &live)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, "ok", live.Status)
+
+	var ready entity.ReadinessResponse
+	resp = ts.do(fiber.MethodGet, "/api/health/ready", nil, &ready)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, entity.HealthStatusOK, ready.Status)
+	require.Len(t, ready.Checks, 3)
+	for name, check := range ready.Checks {
+		require.Equal(t, entity.HealthStatusOK, check.Status, name)
+	}
+	require.True(t, ready.Checks["postgres"].Critical)
+	require.True(t, ready.Checks["redis"].Critical)
+	require.False(t, ready.Checks["notifications"].Critical)
+
+	// Dead notifications degrade readiness without taking the task out of
+	// the load balancer.
+	ts.outbox.Messages = append(ts.outbox.Messages, model.OutboxMessage{ID: uuid.New(), Status: model.OutboxStatusDead})
+	ready = entity.ReadinessResponse{}
+	resp = ts.do(fiber.MethodGet, "/api/health/ready", nil, &ready)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, entity.HealthStatusDegraded, ready.Status)
+	require.Equal(t, entity.HealthStatusUnavailable, ready.Checks["notifications"].Status)
+
+	// A critical dependency going down does.
+	ts.health.Err = errors.New("connection refused")
+	ready = entity.ReadinessResponse{}
+	resp = ts.do(fiber.MethodGet, "/api/health/ready", nil, nil)
+	require.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NotContains(t, string(body), "connection refused")
+	require.NoError(t, json.Unmarshal(body, &ready))
+	require.Equal(t, entity.HealthStatusUnavailable, ready.Status)
+	require.Equal(t, entity.HealthStatusUnavailable, ready.Checks["postgres"].Status)
+	require.Equal(t, entity.HealthStatusOK, ready.Checks["redis"].Status)
+
+	// Liveness never looks at dependencies.
+	resp = ts.do(fiber.MethodGet, "/api/health/live", nil, nil)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
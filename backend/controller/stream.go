@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
+	"github.com/valyala/fasthttp"
+)
+
+// IssueStreamTicket handles POST /question/:id/stream/ticket.
+// EventSource and browser WebSocket requests cannot carry an Authorization
+// header, so clients trade their access token for a short-lived, single-use
+// ticket and open the stream with ?ticket=.
+func (s *Server) IssueStreamTicket(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: IssueStreamTicket] Called")
+
+	actor, err := s.requestActor(c, "IssueStreamTicket")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	questionID := c.Params("id")
+	ticket, err := s.tokenService.IssueStreamTicket(c.UserContext(), actor.UserID, actor.Role, questionID)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: IssueStreamTicket] Service error:", err)
+		return serverError(c, err, "Could not issue stream ticket")
+	}
+	return c.Status(fiber.StatusCreated).JSON(entity.StreamTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(constant.StreamTicketTTL.Seconds()),
+	})
+}
+
+// StreamTicketMiddleware authenticates live streams by the ?ticket= from
+// IssueStreamTicket. Requests without one need a regular access token.
+func (s *Server) StreamTicketMiddleware(c *fiber.Ctx) error {
+	ticket := c.Query("ticket")
+	if ticket == "" {
+		return s.JWTMiddleware(c)
+	}
+
+	userID, role, err := s.tokenService.RedeemStreamTicket(c.UserContext(), ticket, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStreamTicket) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: StreamTicketMiddleware] Rejected ticket")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired stream ticket"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: StreamTicketMiddleware] Service error:", err)
+		return serverError(c, err, "Could not check stream ticket")
+	}
+
+	setIdentity(c, userID, role)
+	return c.Next()
+}
+
+// StreamQuestion handles GET /question/:id/stream.
+// It pushes live tallies as Server-Sent Events, or over a WebSocket when the
+// client asks for an upgrade.
+func (s *Server) StreamQuestion(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: StreamQuestion] Called")
+
+	if websocket.IsWebSocketUpgrade(c) {
+		return websocket.New(s.streamQuestionWebSocket)(c)
+	}
+
+	questionID := c.Params("id")
+
+	// The fasthttp context is recycled once the handler returns, so the
+	// subscription lives on its own context cancelled when the client leaves.
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "userID", c.Locals("userID")))
+	events, err := s.questionService.SubscribeQuestion(ctx, questionID)
+	if err != nil {
+		cancel()
+		if errors.Is(err, service.ErrQuestionNotFound) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: StreamQuestion] Question not found:", questionID)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Question not found"})
+		}
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: StreamQuestion] Service error:", err)
+		return serverError(c, err, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		s.logger.InfoWithID(ctx, "[Controller: StreamQuestion] Streaming question:", questionID)
+
+		heartbeat := time.NewTicker(constant.StreamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(event)
+				if err != nil {
+					s.logger.ErrorWithID(ctx, "[Controller: StreamQuestion] Error encoding event:", err)
+					continue
+				}
+				fmt.Fprintf(w, "event: tally\ndata: %s\n\n", payload)
+			case <-heartbeat.C:
+				// Comment lines keep the ALB from closing an idle connection.
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				s.logger.InfoWithID(ctx, "[Controller: StreamQuestion] Client disconnected:", questionID)
+				return
+			}
+		}
+	}))
+	return nil
+}
+
+// streamQuestionWebSocket pushes the same tallies as StreamQuestion as JSON
+// text frames.
+func (s *Server) streamQuestionWebSocket(conn *websocket.Conn) {
+	questionID := conn.Params("id")
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "userID", conn.Locals("userID")))
+	defer cancel()
+
+	events, err := s.questionService.SubscribeQuestion(ctx, questionID)
+	if err != nil {
+		s.logger.ErrorWithID(ctx, "[Controller: StreamQuestion] Service error:", err)
+		_ = conn.WriteJSON(fiber.Map{"error": err.Error()})
+		return
+	}
+
+	// Reading is the only way to notice the client going away.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(constant.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(constant.HTTPTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
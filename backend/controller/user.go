@@ -1,34 +1,39 @@
 package controller
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/guncv/Poll-Voting-Website/backend/service"
 )
 
 // Register a new user
 func (s *Server) Register(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: Register] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Register] Called")
 	
 	var req entity.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: Register] Error parsing request body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Register] Error parsing request body:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: Register] Request parsed for email:", req.Email)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Register] Request parsed for email:", req.Email)
 
 	// Pass the request context to the service call.
-	user, err := s.userService.Register(c.Context(), req.Email, req.Password)
+	user, err := s.userService.Register(c.UserContext(), req.Email, req.Password)
 	if err != nil {
 		if err.Error() == "user already exists" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: Register] User already exists for email:", req.Email)
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: Register] User already exists for email:", req.Email)
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: Register] Service error:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: Register] Service error:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: Register] User registered successfully for email:", req.Email)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: Register] User registered successfully for email:", req.Email)
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"user_id": user.UserID,
 		"email":   user.Email,
@@ -37,78 +42,148 @@ func (s *Server) Register(c *fiber.Ctx) error {
 
 // GetUser by ID
 func (s *Server) GetUser(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: GetUser] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetUser] Called")
 	
 	// Get the user ID as string directly.
 	id := c.Params("id")
 	if id == "" {
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetUser] Missing user ID")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetUser] Missing user ID")
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
 	
-	u, err := s.userService.GetUserByID(c.Context(), id)
+	u, err := s.userService.GetUserByID(c.UserContext(), id)
 	if err != nil {
 		if err.Error() == "user not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: GetUser] User not found with id:", id)
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: GetUser] User not found with id:", id)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: GetUser] Service error:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: GetUser] Service error:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: GetUser] User retrieved with id:", id)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: GetUser] User retrieved with id:", id)
 	return c.Status(fiber.StatusOK).JSON(u)
 }
 
 // DeleteUser
 func (s *Server) DeleteUser(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: DeleteUser] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: DeleteUser] Called")
 	
 	// Get the user ID as string.
 	id := c.Params("id")
 	if id == "" {
-		s.logger.ErrorWithID(c.Context(), "[Controller: DeleteUser] Missing user ID")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteUser] Missing user ID")
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
 	
-	if err := s.userService.DeleteUser(c.Context(), id); err != nil {
+	actor, err := s.requestActor(c, "DeleteUser")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	if err := s.userService.DeleteUser(c.UserContext(), actor, id); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteUser] Forbidden for user id:", id)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
 		if err.Error() == "user not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: DeleteUser] User not found with id:", id)
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteUser] User not found with id:", id)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: DeleteUser] Service error:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteUser] Service error:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: DeleteUser] User deleted with id:", id)
+	// A deleted account must not keep refreshing its access tokens.
+	if err := s.tokenService.RevokeAll(c.UserContext(), id); err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: DeleteUser] Error revoking sessions:", err)
+		return serverError(c, err, "Could not revoke sessions")
+	}
+	s.logger.InfoWithID(c.UserContext(), "[Controller: DeleteUser] User deleted with id:", id)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "User deleted successfully"})
 }
 
 // UpdateUser
 func (s *Server) UpdateUser(c *fiber.Ctx) error {
-	s.logger.InfoWithID(c.Context(), "[Controller: UpdateUser] Called")
+	s.logger.InfoWithID(c.UserContext(), "[Controller: UpdateUser] Called")
 	
 	// Get the user ID as string.
 	id := c.Params("id")
 	if id == "" {
-		s.logger.ErrorWithID(c.Context(), "[Controller: UpdateUser] Missing user ID")
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] Missing user ID")
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
 
 	var req entity.UpdateUserRequest
 	if err := c.BodyParser(&req); err != nil {
-		s.logger.ErrorWithID(c.Context(), "[Controller: UpdateUser] Error parsing request body:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] Error parsing request body:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	s.logger.InfoWithID(c.Context(), "[Controller: UpdateUser] Request parsed for user id:", id)
-	u, err := s.userService.UpdateUser(c.Context(), id, req.Email, req.Password)
+	s.logger.InfoWithID(c.UserContext(), "[Controller: UpdateUser] Request parsed for user id:", id)
+	actor, err := s.requestActor(c, "UpdateUser")
 	if err != nil {
+		return identityError(c, err)
+	}
+
+	u, err := s.userService.UpdateUser(c.UserContext(), actor, id, req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] Forbidden for user id:", id)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
 		if err.Error() == "user not found" {
-			s.logger.ErrorWithID(c.Context(), "[Controller: UpdateUser] User not found with id:", id)
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] User not found with id:", id)
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
-		s.logger.ErrorWithID(c.Context(), "[Controller: UpdateUser] Service error:", err)
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] Service error:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	s.logger.InfoWithID(c.Context(), "[Controller: UpdateUser] User updated successfully with id:", id)
+	// A new password ends every session, including one opened by whoever
+	// knew the old password.
+	if req.Password != "" {
+		if err := s.tokenService.RevokeAll(c.UserContext(), id); err != nil {
+			s.logger.ErrorWithID(c.UserContext(), "[Controller: UpdateUser] Error revoking sessions:", err)
+			return serverError(c, err, "Could not revoke sessions")
+		}
+		if actor.UserID == id {
+			clearRefreshCookie(c)
+		}
+	}
+	s.logger.InfoWithID(c.UserContext(), "[Controller: UpdateUser] User updated successfully with id:", id)
 	return c.Status(fiber.StatusOK).JSON(u)
 }
+
+// MyVotes handles GET /user/me/votes?from=YYYY-MM-DD&to=YYYY-MM-DD&limit=&offset=
+func (s *Server) MyVotes(c *fiber.Ctx) error {
+	s.logger.InfoWithID(c.UserContext(), "[Controller: MyVotes] Called")
+
+	userID, err := s.requestUserID(c, "MyVotes", "")
+	if err != nil {
+		return identityError(c, err)
+	}
+
+	filter := repository.VoteFilter{
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		Limit:  c.QueryInt("limit", 20),
+		Offset: c.QueryInt("offset", 0),
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "limit must be between 1 and 100"})
+	}
+	if filter.Offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "offset must not be negative"})
+	}
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from and to must be dates formatted YYYY-MM-DD"})
+		}
+	}
+
+	history, err := s.questionService.ListUserVotes(c.UserContext(), userID, filter)
+	if err != nil {
+		s.logger.ErrorWithID(c.UserContext(), "[Controller: MyVotes] Service error:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(history)
+}
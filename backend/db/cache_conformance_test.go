@@ -0,0 +1,453 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheHarness is one CacheService under test and a way to move its clock
+// forward so TTLs can expire.
+type cacheHarness struct {
+	cache   CacheService
+	advance func(d time.Duration)
+}
+
+func newMemoryHarness(t *testing.T) cacheHarness {
+	cache := NewMemoryCacheService()
+	var mu sync.Mutex
+	offset := time.Duration(0)
+	cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return time.Now().Add(offset)
+	}
+	return cacheHarness{
+		cache: cache,
+		advance: func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			offset += d
+		},
+	}
+}
+
+func newRedisHarness(t *testing.T) cacheHarness {
+	mr := miniredis.RunT(t)
+	var cfg config.Config
+	cfg.RedisConfig.Host = mr.Host()
+	cfg.RedisConfig.Port = mr.Port()
+	return cacheHarness{
+		cache:   NewRedisCacheService(cfg),
+		advance: mr.FastForward,
+	}
+}
+
+func TestMemoryCacheService(t *testing.T) {
+	testCacheService(t, newMemoryHarness)
+}
+
+func TestRedisCacheService(t *testing.T) {
+	testCacheService(t, newRedisHarness)
+}
+
+// testCacheService is the behavior every CacheService implementation must
+// share. Each subtest gets a fresh, empty cache.
+func testCacheService(t *testing.T, newHarness func(t *testing.T) cacheHarness) {
+	ctx := context.Background()
+	now := time.Now()
+	unix := func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) }
+	keys := VoteKeys{
+		Question:     "question:2025-01-01:q1",
+		Votes:        "votes:2025-01-01:q1",
+		LegacyVoters: "voted:2025-01-01:q1",
+		Revealed:     "revealed:q1",
+	}
+	openPoll := func(t *testing.T, cache CacheService, extra map[string]string) {
+		data := map[string]string{
+			"question_text":      "Tea or coffee?",
+			"first_choice_count": "0", "second_choice_count": "0", "total_participants": "0",
+			"opens_at": unix(now.Add(-time.Hour)), "closes_at": unix(now.Add(time.Hour)),
+		}
+		for field, value := range extra {
+			data[field] = value
+		}
+		require.NoError(t, cache.SetHash(ctx, keys.Question, data))
+	}
+
+	t.Run("strings", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		val, err := cache.Get(ctx, "missing")
+		require.NoError(t, err)
+		require.Empty(t, val)
+
+		require.NoError(t, cache.Set(ctx, "k", "v1"))
+		val, err = cache.Get(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v1", val)
+
+		val, err = cache.GetAndDelete(ctx, "k")
+		require.NoError(t, err)
+		require.Equal(t, "v1", val)
+		val, err = cache.GetAndDelete(ctx, "k")
+		require.NoError(t, err)
+		require.Empty(t, val)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		h := newHarness(t)
+
+		require.NoError(t, h.cache.SetWithTTL(ctx, "short", "v", time.Minute))
+		require.NoError(t, h.cache.SetWithTTL(ctx, "cleared", "v", time.Minute))
+		require.NoError(t, h.cache.Set(ctx, "cleared", "v2"))
+		require.NoError(t, h.cache.SetHash(ctx, "hash", map[string]string{"a": "1"}))
+		require.NoError(t, h.cache.SetTTL(ctx, "hash", time.Minute))
+		require.NoError(t, h.cache.AddToSet(ctx, "gone", "m"))
+		require.NoError(t, h.cache.SetTTL(ctx, "gone", 0))
+		require.NoError(t, h.cache.SetTTL(ctx, "missing", time.Minute))
+
+		val, err := h.cache.Get(ctx, "short")
+		require.NoError(t, err)
+		require.Equal(t, "v", val)
+		members, err := h.cache.GetSetMembers(ctx, "gone")
+		require.NoError(t, err)
+		require.Empty(t, members)
+
+		h.advance(2 * time.Minute)
+
+		val, err = h.cache.Get(ctx, "short")
+		require.NoError(t, err)
+		require.Empty(t, val)
+		val, err = h.cache.Get(ctx, "cleared")
+		require.NoError(t, err)
+		require.Equal(t, "v2", val)
+		data, err := h.cache.GetAllHash(ctx, "hash")
+		require.NoError(t, err)
+		require.Empty(t, data)
+	})
+
+	t.Run("hashes", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		data, err := cache.GetAllHash(ctx, "missing")
+		require.NoError(t, err)
+		require.NotNil(t, data)
+		require.Empty(t, data)
+		n, err := cache.GetFieldInt(ctx, "missing", "count")
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		require.NoError(t, cache.SetHash(ctx, "h", map[string]string{"name": "poll", "count": "2"}))
+		require.NoError(t, cache.SetHash(ctx, "h", map[string]string{"extra": "x"}))
+		val, err := cache.GetField(ctx, "h", "name")
+		require.NoError(t, err)
+		require.Equal(t, "poll", val)
+		val, err = cache.GetField(ctx, "h", "missing")
+		require.NoError(t, err)
+		require.Empty(t, val)
+
+		total, err := cache.IncrementField(ctx, "h", "count")
+		require.NoError(t, err)
+		require.EqualValues(t, 3, total)
+		total, err = cache.IncrementField(ctx, "h", "fresh")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, total)
+		n, err = cache.GetFieldInt(ctx, "h", "count")
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+
+		_, err = cache.IncrementField(ctx, "h", "name")
+		require.Error(t, err)
+
+		data, err = cache.GetAllHash(ctx, "h")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"name": "poll", "count": "3", "extra": "x", "fresh": "1"}, data)
+
+		require.NoError(t, cache.DeleteField(ctx, "h", "fresh"))
+		require.NoError(t, cache.DeleteField(ctx, "h", "fresh"))
+		require.NoError(t, cache.DeleteField(ctx, "missing", "fresh"))
+		data, err = cache.GetAllHash(ctx, "h")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"name": "poll", "count": "3", "extra": "x"}, data)
+
+		require.NoError(t, cache.DeleteKey(ctx, "h"))
+		data, err = cache.GetAllHash(ctx, "h")
+		require.NoError(t, err)
+		require.Empty(t, data)
+	})
+
+	t.Run("sets", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		members, err := cache.GetSetMembers(ctx, "missing")
+		require.NoError(t, err)
+		require.Empty(t, members)
+
+		require.NoError(t, cache.AddToSet(ctx, "s", "a"))
+		require.NoError(t, cache.AddSetMember(ctx, "s", "b"))
+		require.NoError(t, cache.AddToSet(ctx, "s", "a"))
+
+		ok, err := cache.IsSetMember(ctx, "s", "b")
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = cache.IsSetMember(ctx, "s", "c")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		members, err = cache.GetSetMembers(ctx, "s")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a", "b"}, members)
+	})
+
+	t.Run("record vote", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		_, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		openPoll(t, cache, nil)
+		result, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.False(t, result.AlreadyVoted)
+		require.False(t, result.Changed)
+		require.Empty(t, result.NewlyRevealed)
+		require.Equal(t, "1", result.Question["first_choice_count"])
+		require.Equal(t, "1", result.Question["total_participants"])
+		require.Equal(t, "Tea or coffee?", result.Question["question_text"])
+
+		result, err = cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.True(t, result.AlreadyVoted)
+
+		result, err = cache.RecordVote(ctx, keys, "u1", "second_choice_count", 0)
+		require.NoError(t, err)
+		require.True(t, result.Changed)
+		require.Equal(t, "0", result.Question["first_choice_count"])
+		require.Equal(t, "1", result.Question["second_choice_count"])
+		require.Equal(t, "1", result.Question["total_participants"])
+
+		choice, err := cache.GetField(ctx, keys.Votes, "u1")
+		require.NoError(t, err)
+		require.Equal(t, "second_choice_count", choice)
+
+		require.NoError(t, cache.AddToSet(ctx, keys.LegacyVoters, "legacy"))
+		result, err = cache.RecordVote(ctx, keys, "legacy", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.True(t, result.AlreadyVoted)
+		require.Equal(t, "1", result.Question["total_participants"])
+	})
+
+	t.Run("locked votes", func(t *testing.T) {
+		cache := newHarness(t).cache
+		openPoll(t, cache, map[string]string{"lock_votes": "1"})
+
+		_, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+		result, err := cache.RecordVote(ctx, keys, "u1", "second_choice_count", 0)
+		require.NoError(t, err)
+		require.True(t, result.AlreadyVoted)
+		require.Equal(t, "1", result.Question["first_choice_count"])
+		require.Equal(t, "0", result.Question["second_choice_count"])
+
+		_, err = cache.RetractVote(ctx, keys, "u1")
+		require.ErrorIs(t, err, ErrVotesLocked)
+	})
+
+	t.Run("voting window", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		openPoll(t, cache, map[string]string{"opens_at": unix(now.Add(time.Hour))})
+		_, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.ErrorIs(t, err, ErrVotingClosed)
+
+		openPoll(t, cache, map[string]string{"closes_at": unix(now.Add(-time.Minute))})
+		_, err = cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.ErrorIs(t, err, ErrVotingClosed)
+		_, err = cache.RetractVote(ctx, keys, "u1")
+		require.ErrorIs(t, err, ErrVotingClosed)
+
+		require.NoError(t, cache.SetHash(ctx, "question:legacy", map[string]string{"total_participants": "0"}))
+		legacy := keys
+		legacy.Question = "question:legacy"
+		_, err = cache.RecordVote(ctx, legacy, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("milestones", func(t *testing.T) {
+		cache := newHarness(t).cache
+		openPoll(t, cache, map[string]string{"milestones": "2:followA, 02:followB,3 : followC"})
+
+		result, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.Empty(t, result.NewlyRevealed)
+
+		// 2 and 02 are the same threshold, so only the first one is revealed.
+		result, err = cache.RecordVote(ctx, keys, "u2", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{"followA"}, result.NewlyRevealed)
+
+		_, err = cache.RetractVote(ctx, keys, "u2")
+		require.NoError(t, err)
+		result, err = cache.RecordVote(ctx, keys, "u2", "first_choice_count", 0)
+		require.NoError(t, err)
+		require.Empty(t, result.NewlyRevealed)
+
+		result, err = cache.RecordVote(ctx, keys, "u3", "second_choice_count", 0)
+		require.NoError(t, err)
+		require.Equal(t, []string{"followC"}, result.NewlyRevealed)
+
+		revealed, err := cache.GetSetMembers(ctx, keys.Revealed)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"2", "3"}, revealed)
+	})
+
+	t.Run("vote keys follow the question ttl", func(t *testing.T) {
+		h := newHarness(t)
+		openPoll(t, h.cache, map[string]string{"milestones": "1:followA"})
+		require.NoError(t, h.cache.SetTTL(ctx, keys.Question, time.Hour))
+
+		_, err := h.cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+
+		h.advance(2 * time.Hour)
+		for _, key := range []string{keys.Question, keys.Votes} {
+			data, err := h.cache.GetAllHash(ctx, key)
+			require.NoError(t, err)
+			require.Empty(t, data, key)
+		}
+		revealed, err := h.cache.GetSetMembers(ctx, keys.Revealed)
+		require.NoError(t, err)
+		require.Empty(t, revealed)
+	})
+
+	t.Run("retract vote", func(t *testing.T) {
+		cache := newHarness(t).cache
+
+		_, err := cache.RetractVote(ctx, keys, "u1")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		openPoll(t, cache, nil)
+		_, err = cache.RetractVote(ctx, keys, "u1")
+		require.ErrorIs(t, err, ErrNoVote)
+
+		_, err = cache.RecordVote(ctx, keys, "u1", "first_choice_count", 0)
+		require.NoError(t, err)
+		result, err := cache.RetractVote(ctx, keys, "u1")
+		require.NoError(t, err)
+		require.Equal(t, "0", result.Question["first_choice_count"])
+		require.Equal(t, "0", result.Question["total_participants"])
+
+		choice, err := cache.GetField(ctx, keys.Votes, "u1")
+		require.NoError(t, err)
+		require.Empty(t, choice)
+		_, err = cache.RetractVote(ctx, keys, "u1")
+		require.ErrorIs(t, err, ErrNoVote)
+	})
+
+	t.Run("alert threshold", func(t *testing.T) {
+		cache := newHarness(t).cache
+		openPoll(t, cache, nil)
+
+		result, err := cache.RecordVote(ctx, keys, "u1", "first_choice_count", 2)
+		require.NoError(t, err)
+		require.False(t, result.ThresholdReached)
+		result, err = cache.RecordVote(ctx, keys, "u2", "first_choice_count", 2)
+		require.NoError(t, err)
+		require.True(t, result.ThresholdReached)
+		require.Equal(t, "1", result.Question["alerted"])
+
+		// Dropping below the threshold and crossing it again does not alert
+		// twice.
+		_, err = cache.RetractVote(ctx, keys, "u2")
+		require.NoError(t, err)
+		result, err = cache.RecordVote(ctx, keys, "u2", "second_choice_count", 2)
+		require.NoError(t, err)
+		require.False(t, result.ThresholdReached)
+		result, err = cache.RecordVote(ctx, keys, "u3", "second_choice_count", 2)
+		require.NoError(t, err)
+		require.False(t, result.ThresholdReached)
+
+		// Clearing alerted, as when the alert could not be queued, lets the
+		// next vote alert again.
+		require.NoError(t, cache.DeleteField(ctx, keys.Question, "alerted"))
+		result, err = cache.RecordVote(ctx, keys, "u4", "second_choice_count", 2)
+		require.NoError(t, err)
+		require.True(t, result.ThresholdReached)
+	})
+
+	t.Run("concurrent votes", func(t *testing.T) {
+		cache := newHarness(t).cache
+		openPoll(t, cache, nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				_, err := cache.RecordVote(ctx, keys, fmt.Sprintf("user-%d", i), "first_choice_count", 0)
+				require.NoError(t, err)
+			}(i)
+			go func() {
+				defer wg.Done()
+				_, err := cache.RecordVote(ctx, keys, "repeat", "second_choice_count", 0)
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		data, err := cache.GetAllHash(ctx, keys.Question)
+		require.NoError(t, err)
+		require.Equal(t, "20", data["first_choice_count"])
+		require.Equal(t, "1", data["second_choice_count"])
+		require.Equal(t, "21", data["total_participants"])
+	})
+
+	t.Run("publish and subscribe", func(t *testing.T) {
+		cache := newHarness(t).cache
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		messages, err := cache.Subscribe(subCtx, "question_events:q1")
+		require.NoError(t, err)
+		require.NoError(t, cache.Publish(ctx, "question_events:other", "ignored"))
+		require.NoError(t, cache.Publish(ctx, "question_events:q1", "tally"))
+
+		select {
+		case msg := <-messages:
+			require.Equal(t, "tally", msg)
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+		}
+
+		cancel()
+		select {
+		case _, ok := <-messages:
+			require.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("channel not closed after cancel")
+		}
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cache := newHarness(t).cache
+		require.NoError(t, cache.Ping(ctx))
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		require.ErrorIs(t, cache.Ping(cancelled), ErrCacheUnavailable)
+		_, err := cache.Get(cancelled, "k")
+		require.ErrorIs(t, err, ErrCacheUnavailable)
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, cache.SetHash(cancelled, "h", map[string]string{"a": "1"}), ErrCacheUnavailable)
+		_, err = cache.RecordVote(cancelled, keys, "u1", "first_choice_count", 0)
+		require.ErrorIs(t, err, ErrCacheUnavailable)
+	})
+}
@@ -0,0 +1,574 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errWrongType mirrors the error Redis returns when a command targets a key
+// holding another kind of value.
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// memorySweepInterval is how often writes also drop every expired key, so keys
+// nobody reads again do not pile up.
+const memorySweepInterval = time.Minute
+
+// subscriberBuffer is how many messages a slow subscriber may fall behind
+// before further messages are dropped, like the go-redis channel does.
+const subscriberBuffer = 100
+
+// milestonePattern matches one threshold:follow-up pair of the milestones
+// field, like the Lua pattern of voteScript.
+var milestonePattern = regexp.MustCompile(`^\s*(\d+)\s*:\s*(.*?)\s*$`)
+
+// memoryEntry holds one key. Exactly one of value, hash and set is in use.
+type memoryEntry struct {
+	value     string
+	hash      map[string]string
+	set       map[string]struct{}
+	expiresAt time.Time // zero means no expiry
+}
+
+// MemoryCacheService is an in-process CacheService for tests and single-node
+// development. It follows the semantics of RedisCacheService, but its data is
+// neither persisted nor shared between instances.
+type MemoryCacheService struct {
+	mu          sync.Mutex
+	entries     map[string]*memoryEntry
+	subscribers map[string]map[chan string]struct{}
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+func NewMemoryCacheService() *MemoryCacheService {
+	return &MemoryCacheService{
+		entries:     map[string]*memoryEntry{},
+		subscribers: map[string]map[chan string]struct{}{},
+		now:         time.Now,
+	}
+}
+
+// entry returns the live entry of key, dropping it first if it has expired.
+// Callers must hold mu.
+func (m *MemoryCacheService) entry(key string) *memoryEntry {
+	e, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	if !e.expiresAt.IsZero() && !m.now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil
+	}
+	return e
+}
+
+// sweep drops every expired key at most once per memorySweepInterval.
+// Callers must hold mu.
+func (m *MemoryCacheService) sweep() {
+	now := m.now()
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key := range m.entries {
+		m.entry(key)
+	}
+}
+
+// hash returns the hash stored at key, creating it when create is set.
+// Callers must hold mu.
+func (m *MemoryCacheService) hash(key string, create bool) (map[string]string, error) {
+	e := m.entry(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		m.sweep()
+		e = &memoryEntry{hash: map[string]string{}}
+		m.entries[key] = e
+	}
+	if e.hash == nil {
+		return nil, unavailable(errWrongType)
+	}
+	return e.hash, nil
+}
+
+// set returns the set stored at key, creating it when create is set.
+// Callers must hold mu.
+func (m *MemoryCacheService) set(key string, create bool) (map[string]struct{}, error) {
+	e := m.entry(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		m.sweep()
+		e = &memoryEntry{set: map[string]struct{}{}}
+		m.entries[key] = e
+	}
+	if e.set == nil {
+		return nil, unavailable(errWrongType)
+	}
+	return e.set, nil
+}
+
+// str returns the string stored at key and whether it exists.
+// Callers must hold mu.
+func (m *MemoryCacheService) str(key string) (string, bool, error) {
+	e := m.entry(key)
+	if e == nil {
+		return "", false, nil
+	}
+	if e.hash != nil || e.set != nil {
+		return "", false, unavailable(errWrongType)
+	}
+	return e.value, true, nil
+}
+
+// incrementHash adds delta to field of h, like HINCRBY.
+func incrementHash(h map[string]string, field string, delta int64) (int64, error) {
+	current := int64(0)
+	if raw, ok := h[field]; ok {
+		var err error
+		current, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, unavailable(errors.New("ERR hash value is not an integer"))
+		}
+	}
+	current += delta
+	h[field] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+func copyHash(h map[string]string) map[string]string {
+	data := make(map[string]string, len(h))
+	for field, value := range h {
+		data[field] = value
+	}
+	return data
+}
+
+func (m *MemoryCacheService) Get(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	val, _, err := m.str(key)
+	return val, err
+}
+
+func (m *MemoryCacheService) Set(ctx context.Context, key string, value string) error {
+	return m.SetWithTTL(ctx, key, value, 0)
+}
+
+func (m *MemoryCacheService) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	e := &memoryEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = m.now().Add(ttl)
+	}
+	m.entries[key] = e
+	return nil
+}
+
+func (m *MemoryCacheService) GetAndDelete(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	val, ok, err := m.str(key)
+	if err != nil || !ok {
+		return "", err
+	}
+	delete(m.entries, key)
+	return val, nil
+}
+
+func (m *MemoryCacheService) IsSetMember(ctx context.Context, key, member string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.set(key, false)
+	if err != nil {
+		return false, err
+	}
+	_, ok := s[member]
+	return ok, nil
+}
+
+func (m *MemoryCacheService) AddSetMember(ctx context.Context, key, member string) error {
+	return m.AddToSet(ctx, key, member)
+}
+
+func (m *MemoryCacheService) IncrementField(ctx context.Context, key, field string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.hash(key, true)
+	if err != nil {
+		return 0, err
+	}
+	return incrementHash(h, field, 1)
+}
+
+func (m *MemoryCacheService) GetField(ctx context.Context, key, field string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.hash(key, false)
+	if err != nil {
+		return "", err
+	}
+	return h[field], nil
+}
+
+func (m *MemoryCacheService) GetFieldInt(ctx context.Context, key, field string) (int, error) {
+	valStr, err := m.GetField(ctx, key, field)
+	if err != nil || valStr == "" {
+		return 0, err
+	}
+	return strconv.Atoi(valStr)
+}
+
+func (m *MemoryCacheService) SetHash(ctx context.Context, key string, data map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.hash(key, true)
+	if err != nil {
+		return err
+	}
+	for field, value := range data {
+		h[field] = value
+	}
+	if len(h) == 0 {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *MemoryCacheService) DeleteField(ctx context.Context, key, field string) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.hash(key, false)
+	if err != nil || h == nil {
+		return err
+	}
+	delete(h, field)
+	if len(h) == 0 {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *MemoryCacheService) GetAllHash(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, err := m.hash(key, false)
+	if err != nil {
+		return nil, err
+	}
+	return copyHash(h), nil
+}
+
+func (m *MemoryCacheService) AddToSet(ctx context.Context, key, value string) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.set(key, true)
+	if err != nil {
+		return err
+	}
+	s[value] = struct{}{}
+	return nil
+}
+
+func (m *MemoryCacheService) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.set(key, false)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(s))
+	for member := range s {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (m *MemoryCacheService) DeleteKey(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// SetTTL behaves like EXPIRE: a missing key is left alone and a ttl that is
+// not positive deletes the key.
+func (m *MemoryCacheService) SetTTL(ctx context.Context, key string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.entry(key)
+	if e == nil {
+		return nil
+	}
+	if ttl <= 0 {
+		delete(m.entries, key)
+		return nil
+	}
+	e.expiresAt = m.now().Add(ttl)
+	return nil
+}
+
+// checkOpenPoll is openPollCheck: it returns the question hash, or
+// ErrKeyNotFound or ErrVotingClosed, and whether the poll sets lock_votes.
+// Callers must hold mu.
+func (m *MemoryCacheService) checkOpenPoll(key string) (map[string]string, bool, error) {
+	question, err := m.hash(key, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if question == nil {
+		return nil, false, ErrKeyNotFound
+	}
+	now := float64(m.now().Unix())
+	if opensAt, err := strconv.ParseFloat(question["opens_at"], 64); err == nil && now < opensAt {
+		return nil, false, ErrVotingClosed
+	}
+	if closesAt, err := strconv.ParseFloat(question["closes_at"], 64); err == nil && now >= closesAt {
+		return nil, false, ErrVotingClosed
+	}
+	return question, question["lock_votes"] == "1", nil
+}
+
+func (m *MemoryCacheService) RecordVote(ctx context.Context, keys VoteKeys, userID, countField string, alertAt int) (VoteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return VoteResult{}, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	question, locked, err := m.checkOpenPoll(keys.Question)
+	if err != nil {
+		return VoteResult{}, err
+	}
+	result := VoteResult{NewlyRevealed: []string{}}
+
+	legacy, err := m.set(keys.LegacyVoters, false)
+	if err != nil {
+		return VoteResult{}, err
+	}
+	if _, ok := legacy[userID]; ok {
+		result.AlreadyVoted = true
+		result.Question = copyHash(question)
+		return result, nil
+	}
+
+	votes, err := m.hash(keys.Votes, true)
+	if err != nil {
+		return VoteResult{}, err
+	}
+	if previous, ok := votes[userID]; ok {
+		if previous == countField || locked {
+			result.AlreadyVoted = true
+			result.Question = copyHash(question)
+			return result, nil
+		}
+		if _, err := incrementHash(question, previous, -1); err != nil {
+			return VoteResult{}, err
+		}
+		if _, err := incrementHash(question, countField, 1); err != nil {
+			return VoteResult{}, err
+		}
+		votes[userID] = countField
+		result.Changed = true
+		result.Question = copyHash(question)
+		return result, nil
+	}
+
+	votes[userID] = countField
+	if _, err := incrementHash(question, countField, 1); err != nil {
+		return VoteResult{}, err
+	}
+	total, err := incrementHash(question, "total_participants", 1)
+	if err != nil {
+		return VoteResult{}, err
+	}
+
+	if milestones, ok := question["milestones"]; ok {
+		for _, pair := range strings.Split(milestones, ",") {
+			match := milestonePattern.FindStringSubmatch(pair)
+			if match == nil {
+				continue
+			}
+			threshold, err := strconv.ParseInt(match[1], 10, 64)
+			if err != nil || total < threshold {
+				continue
+			}
+			revealed, err := m.set(keys.Revealed, true)
+			if err != nil {
+				return VoteResult{}, err
+			}
+			member := strconv.FormatInt(threshold, 10)
+			if _, ok := revealed[member]; !ok {
+				revealed[member] = struct{}{}
+				result.NewlyRevealed = append(result.NewlyRevealed, match[2])
+			}
+		}
+	}
+
+	if alertAt > 0 && total >= int64(alertAt) {
+		if _, ok := question["alerted"]; !ok {
+			question["alerted"] = "1"
+			result.ThresholdReached = true
+		}
+	}
+
+	// Give the vote keys the remaining lifetime of the question.
+	if expiresAt := m.entries[keys.Question].expiresAt; !expiresAt.IsZero() {
+		for _, key := range []string{keys.Votes, keys.LegacyVoters, keys.Revealed} {
+			if e := m.entry(key); e != nil {
+				e.expiresAt = expiresAt
+			}
+		}
+	}
+	result.Question = copyHash(question)
+	return result, nil
+}
+
+func (m *MemoryCacheService) RetractVote(ctx context.Context, keys VoteKeys, userID string) (VoteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return VoteResult{}, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	question, locked, err := m.checkOpenPoll(keys.Question)
+	if err != nil {
+		return VoteResult{}, err
+	}
+	if locked {
+		return VoteResult{}, ErrVotesLocked
+	}
+	votes, err := m.hash(keys.Votes, false)
+	if err != nil {
+		return VoteResult{}, err
+	}
+	previous, ok := votes[userID]
+	if !ok {
+		return VoteResult{}, ErrNoVote
+	}
+
+	delete(votes, userID)
+	if len(votes) == 0 {
+		delete(m.entries, keys.Votes)
+	}
+	if _, err := incrementHash(question, previous, -1); err != nil {
+		return VoteResult{}, err
+	}
+	if _, err := incrementHash(question, "total_participants", -1); err != nil {
+		return VoteResult{}, err
+	}
+	return VoteResult{NewlyRevealed: []string{}, Question: copyHash(question)}, nil
+}
+
+// Publish delivers message to the subscribers of this instance only.
+func (m *MemoryCacheService) Publish(ctx context.Context, channel, message string) error {
+	if err := ctx.Err(); err != nil {
+		return unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for messages := range m.subscribers[channel] {
+		select {
+		case messages <- message:
+		default: // the subscriber fell too far behind
+		}
+	}
+	return nil
+}
+
+func (m *MemoryCacheService) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, unavailable(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make(chan string, subscriberBuffer)
+	if m.subscribers[channel] == nil {
+		m.subscribers[channel] = map[chan string]struct{}{}
+	}
+	m.subscribers[channel][messages] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.subscribers[channel], messages)
+		if len(m.subscribers[channel]) == 0 {
+			delete(m.subscribers, channel)
+		}
+		close(messages)
+	}()
+	return messages, nil
+}
+
+// Ping only fails once ctx is done; the cache lives in this process.
+func (m *MemoryCacheService) Ping(ctx context.Context) error {
+	return unavailable(ctx.Err())
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,28 @@
+-- Tables that predate versioned migrations. IF NOT EXISTS lets databases
+-- created from the old sql/schema.sql adopt the migration history.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    email      VARCHAR(255) NOT NULL UNIQUE,
+    password   VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS questions (
+  question_id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  archive_date        DATE NOT NULL,
+  question_text       VARCHAR(255) NOT NULL,
+  first_choice        VARCHAR(255) NOT NULL,
+  second_choice       VARCHAR(255) NOT NULL,
+  total_participants  INT NOT NULL DEFAULT 0,
+  first_choice_count  INT NOT NULL DEFAULT 0,
+  second_choice_count INT NOT NULL DEFAULT 0,
+  created_by          UUID,
+  created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT fk_users
+    FOREIGN KEY (created_by)
+    REFERENCES users(user_id)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,23 @@
+-- Choices of a question; positions 0 and 1 mirror first_choice/second_choice.
+CREATE TABLE IF NOT EXISTS question_options (
+  option_id    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  question_id  UUID NOT NULL,
+  position     INT NOT NULL,
+  option_text  VARCHAR(255) NOT NULL,
+  vote_count   INT NOT NULL DEFAULT 0,
+
+  CONSTRAINT fk_questions
+    FOREIGN KEY (question_id)
+    REFERENCES questions(question_id)
+    ON DELETE CASCADE,
+  CONSTRAINT unique_option_position UNIQUE (question_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_options_question_id ON question_options (question_id);
+
+-- Backfill options for questions archived before question_options existed.
+INSERT INTO question_options (question_id, position, option_text, vote_count)
+SELECT question_id, 0, first_choice, first_choice_count FROM questions
+UNION ALL
+SELECT question_id, 1, second_choice, second_choice_count FROM questions
+ON CONFLICT (question_id, position) DO NOTHING;
@@ -0,0 +1,21 @@
+-- Notifications are written here in the same transaction as the change that
+-- triggers them and delivered by a background dispatcher.
+CREATE TABLE IF NOT EXISTS notification_outbox (
+  id               UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind             VARCHAR(50) NOT NULL,
+  payload          JSONB NOT NULL,
+  status           VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+  attempts         INT NOT NULL DEFAULT 0,
+  next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_error       TEXT NOT NULL DEFAULT '',
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_due
+  ON notification_outbox (next_attempt_at)
+  WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_dead
+  ON notification_outbox (created_at)
+  WHERE status = 'dead';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduled_questions;
+ALTER TABLE questions DROP COLUMN IF EXISTS closes_at;
+ALTER TABLE questions DROP COLUMN IF EXISTS opens_at;
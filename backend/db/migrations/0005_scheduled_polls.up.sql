@@ -0,0 +1,25 @@
+-- Polls run between opens_at and closes_at. Polls created for a later time
+-- wait in scheduled_questions until the scheduler moves them into Redis.
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS opens_at TIMESTAMPTZ;
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS closes_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS scheduled_questions (
+  question_id   UUID PRIMARY KEY,
+  user_id       UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  question_text VARCHAR(255) NOT NULL,
+  payload       JSONB NOT NULL,
+  opens_at      TIMESTAMPTZ NOT NULL,
+  closes_at     TIMESTAMPTZ NOT NULL,
+  status        VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'expired')),
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  activated_at  TIMESTAMPTZ,
+
+  CHECK (closes_at > opens_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_questions_due
+  ON scheduled_questions (opens_at)
+  WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_questions_user
+  ON scheduled_questions (user_id, opens_at);
@@ -0,0 +1,16 @@
+-- One row per user and poll with the option they currently back. Written
+-- behind the Redis vote so users can browse their voting history.
+CREATE TABLE IF NOT EXISTS votes (
+  user_id       UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+  question_id   UUID NOT NULL,
+  option_id     VARCHAR(64) NOT NULL,
+  option_text   VARCHAR(255) NOT NULL,
+  question_text VARCHAR(255) NOT NULL,
+  poll_date     DATE NOT NULL,
+  voted_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  PRIMARY KEY (user_id, question_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_votes_user_date
+  ON votes (user_id, poll_date DESC, voted_at DESC);
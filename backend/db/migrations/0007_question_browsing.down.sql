@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_questions_created_by;
+DROP INDEX IF EXISTS idx_questions_margin_of_victory;
+DROP INDEX IF EXISTS idx_questions_total_participants;
+DROP INDEX IF EXISTS idx_questions_archive_date;
+ALTER TABLE questions DROP COLUMN IF EXISTS margin_of_victory;
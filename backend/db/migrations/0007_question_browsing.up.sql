@@ -0,0 +1,24 @@
+-- Lead of the winning option over the runner-up, stored so the archive can be
+-- sorted by it. Backfilled from question_options, falling back to the legacy
+-- first/second choice counts.
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS margin_of_victory INT NOT NULL DEFAULT 0;
+
+UPDATE questions q
+SET margin_of_victory = COALESCE((
+  SELECT MAX(vote_count) FILTER (WHERE rank = 1) - COALESCE(MAX(vote_count) FILTER (WHERE rank = 2), 0)
+  FROM (
+    SELECT vote_count, ROW_NUMBER() OVER (ORDER BY vote_count DESC) AS rank
+    FROM question_options o
+    WHERE o.question_id = q.question_id
+  ) ranked
+), ABS(q.first_choice_count - q.second_choice_count));
+
+-- Keyset pagination walks (sort column, question_id) in either direction.
+CREATE INDEX IF NOT EXISTS idx_questions_archive_date
+  ON questions (archive_date, question_id);
+CREATE INDEX IF NOT EXISTS idx_questions_total_participants
+  ON questions (total_participants, question_id);
+CREATE INDEX IF NOT EXISTS idx_questions_margin_of_victory
+  ON questions (margin_of_victory, question_id);
+CREATE INDEX IF NOT EXISTS idx_questions_created_by
+  ON questions (created_by);
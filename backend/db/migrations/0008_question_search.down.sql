@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_questions_search;
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,11 @@
+-- Full-text index over archived questions. The 'simple' configuration skips
+-- stemming and stop words, so it works the same for Thai and English text.
+-- Question text ranks above choice text.
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(question_text, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(first_choice, '') || ' ' || coalesce(second_choice, '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_questions_search
+  ON questions USING GIN (search_vector);
@@ -0,0 +1,4 @@
+UPDATE scheduled_questions SET status = 'expired' WHERE status = 'held';
+ALTER TABLE scheduled_questions DROP CONSTRAINT IF EXISTS scheduled_questions_status_check;
+ALTER TABLE scheduled_questions ADD CONSTRAINT scheduled_questions_status_check
+  CHECK (status IN ('pending', 'active', 'expired'));
@@ -0,0 +1,5 @@
+-- Follow-up polls wait in scheduled_questions as 'held' until a milestone of
+-- another poll releases them to 'pending' for the scheduler to open.
+ALTER TABLE scheduled_questions DROP CONSTRAINT IF EXISTS scheduled_questions_status_check;
+ALTER TABLE scheduled_questions ADD CONSTRAINT scheduled_questions_status_check
+  CHECK (status IN ('held', 'pending', 'active', 'expired'));
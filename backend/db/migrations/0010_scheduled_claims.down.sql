@@ -0,0 +1 @@
+ALTER TABLE scheduled_questions DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,3 @@
+-- The scheduler leases due polls by setting claimed_until and commits before
+-- opening them, so no row lock is held while a poll is written to Redis.
+ALTER TABLE scheduled_questions ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
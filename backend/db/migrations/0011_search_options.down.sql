@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS question_options_search_refresh ON question_options;
+DROP TRIGGER IF EXISTS questions_search_refresh ON questions;
+DROP FUNCTION IF EXISTS question_options_search_refresh();
+DROP FUNCTION IF EXISTS questions_search_refresh();
+DROP FUNCTION IF EXISTS question_search_vector(UUID, TEXT);
+
+DROP INDEX IF EXISTS idx_questions_search;
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE questions ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(question_text, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(first_choice, '') || ' ' || coalesce(second_choice, '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_questions_search
+  ON questions USING GIN (search_vector);
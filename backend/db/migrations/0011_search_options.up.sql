@@ -0,0 +1,52 @@
+-- Rebuild the full-text index so it covers every option in question_options,
+-- not only first_choice and second_choice. A generated column cannot read
+-- another table, so triggers keep search_vector up to date instead.
+--
+-- The 'simple' configuration splits words on spaces and punctuation. Thai is
+-- written without spaces between words, so a Thai query only matches archived
+-- text it equals up to the next space or punctuation mark.
+DROP INDEX IF EXISTS idx_questions_search;
+ALTER TABLE questions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE questions ADD COLUMN search_vector tsvector;
+
+-- Question text ranks above option text.
+CREATE OR REPLACE FUNCTION question_search_vector(qid UUID, body TEXT) RETURNS tsvector AS $$
+  SELECT setweight(to_tsvector('simple', coalesce(body, '')), 'A') ||
+         setweight(to_tsvector('simple', coalesce(
+           (SELECT string_agg(option_text, ' ' ORDER BY position) FROM question_options WHERE question_id = qid),
+           '')), 'B')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION questions_search_refresh() RETURNS trigger AS $$
+BEGIN
+  NEW.search_vector := question_search_vector(NEW.question_id, NEW.question_text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION question_options_search_refresh() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE questions SET search_vector = question_search_vector(question_id, question_text)
+    WHERE question_id = OLD.question_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE questions SET search_vector = question_search_vector(question_id, question_text)
+    WHERE question_id = NEW.question_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER questions_search_refresh
+  BEFORE INSERT OR UPDATE OF question_text ON questions
+  FOR EACH ROW EXECUTE FUNCTION questions_search_refresh();
+
+CREATE TRIGGER question_options_search_refresh
+  AFTER INSERT OR UPDATE OF option_text, question_id OR DELETE ON question_options
+  FOR EACH ROW EXECUTE FUNCTION question_options_search_refresh();
+
+UPDATE questions SET search_vector = question_search_vector(question_id, question_text);
+
+CREATE INDEX IF NOT EXISTS idx_questions_search
+  ON questions USING GIN (search_vector);
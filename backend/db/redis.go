@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -10,25 +11,213 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// CacheService stores live polls, votes and refresh tokens. Every method
+// honours ctx, and errors from Redis itself wrap ErrCacheUnavailable.
 type CacheService interface {
-	Get(key string) (string, error)
-	Set(key string, value string) error
-	IsSetMember(key, member string) (bool, error)
-	AddSetMember(key, member string) error
-	IncrementField(key, field string) int64
-	GetField(key, field string) (string, error)
-	GetFieldInt(key, field string) (int, error)
-	SetHash(key string, data map[string]string) error
-	GetAllHash(key string) (map[string]string, error)
-	AddToSet(key, value string) error
-	GetSetMembers(key string) ([]string, error)
-	DeleteKey(key string) error
-	SetTTL(key string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string) error
+	SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error
+	// GetAndDelete atomically reads and removes key. A missing key yields "".
+	GetAndDelete(ctx context.Context, key string) (string, error)
+	IsSetMember(ctx context.Context, key, member string) (bool, error)
+	AddSetMember(ctx context.Context, key, member string) error
+	IncrementField(ctx context.Context, key, field string) (int64, error)
+	GetField(ctx context.Context, key, field string) (string, error)
+	GetFieldInt(ctx context.Context, key, field string) (int, error)
+	SetHash(ctx context.Context, key string, data map[string]string) error
+	// DeleteField removes field from the hash at key. Removing the last field
+	// removes the key.
+	DeleteField(ctx context.Context, key, field string) error
+	GetAllHash(ctx context.Context, key string) (map[string]string, error)
+	AddToSet(ctx context.Context, key, value string) error
+	GetSetMembers(ctx context.Context, key string) ([]string, error)
+	DeleteKey(ctx context.Context, key string) error
+	SetTTL(ctx context.Context, key string, ttl time.Duration) error
+	// RecordVote atomically records countField as the choice of userID in
+	// keys.Votes and updates the counters of keys.Question. A first vote
+	// increments countField and total_participants and marks every milestone
+	// the new total has crossed in keys.Revealed; a vote for another option
+	// moves the user's vote unless the poll sets lock_votes. Votes outside the
+	// opens_at/closes_at window of keys.Question fail with ErrVotingClosed.
+	// The first vote that brings total_participants to alertAt sets the
+	// question's alerted field and VoteResult.ThresholdReached; alertAt 0
+	// disables this. Deleting the alerted field lets the next vote alert again.
+	RecordVote(ctx context.Context, keys VoteKeys, userID, countField string, alertAt int) (VoteResult, error)
+	// RetractVote atomically removes the vote of userID and decrements its
+	// counters. Milestones already revealed stay revealed. It fails with
+	// ErrNoVote when the user has not voted, ErrVotesLocked when the poll sets
+	// lock_votes and ErrVotingClosed outside the voting window.
+	RetractVote(ctx context.Context, keys VoteKeys, userID string) (VoteResult, error)
+	// Publish broadcasts message to every subscriber of channel, across all
+	// backend instances sharing the same Redis.
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe delivers messages published on channel until ctx is cancelled,
+	// after which the returned channel is closed.
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+	// Ping checks that the cache is reachable.
+	Ping(ctx context.Context) error
+}
+
+// ErrCacheUnavailable wraps every error caused by Redis being unreachable,
+// timing out or rejecting a command, so callers can answer 503.
+var ErrCacheUnavailable = errors.New("cache unavailable")
+
+// ErrKeyNotFound is returned when an operation requires an existing key.
+var ErrKeyNotFound = errors.New("cache key not found")
+
+// ErrVotingClosed is returned by RecordVote and RetractVote outside the
+// question's opens_at/closes_at window.
+var ErrVotingClosed = errors.New("voting is closed")
+
+var (
+	// ErrVotesLocked is returned by RetractVote for polls that set lock_votes.
+	ErrVotesLocked = errors.New("votes on this poll cannot be changed")
+	// ErrNoVote is returned by RetractVote when the user has not voted.
+	ErrNoVote = errors.New("you have not voted on this poll")
+)
+
+// VoteKeys names the Redis keys of one poll that votes touch.
+type VoteKeys struct {
+	Question string // question hash
+	Votes    string // hash of user ID to the counter field they voted for
+	// LegacyVoters is the voter set used before votes recorded choices.
+	// Its members can no longer change their vote.
+	LegacyVoters string
+	Revealed     string // set of milestone thresholds already crossed
+}
+
+// VoteResult is the outcome of CacheService.RecordVote and RetractVote.
+type VoteResult struct {
+	AlreadyVoted bool
+	// Changed is set when an earlier vote moved to another option.
+	Changed bool
+	// NewlyRevealed holds the follow-up IDs whose milestone this vote crossed.
+	NewlyRevealed []string
+	// ThresholdReached is set on the single vote that first brought the poll
+	// to the alert threshold, however often votes are retracted and recast.
+	ThresholdReached bool
+	// Question is the question hash as it was right after the vote.
+	Question map[string]string
+}
+
+// openPollCheck aborts a vote script with status -1 when the question does
+// not exist and -2 when it is not open. opens_at and closes_at are unix
+// seconds compared against the Redis clock, so every backend instance agrees
+// on when a poll closes.
+const openPollCheck = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return {-1, {}, {}}
+end
+local now = tonumber(redis.call('TIME')[1])
+local opensAt = tonumber(redis.call('HGET', KEYS[1], 'opens_at'))
+local closesAt = tonumber(redis.call('HGET', KEYS[1], 'closes_at'))
+if (opensAt and now < opensAt) or (closesAt and now >= closesAt) then
+	return {-2, {}, {}}
+end
+local locked = redis.call('HGET', KEYS[1], 'lock_votes') == '1'
+`
+
+// extendVoteKeys gives the vote keys the remaining lifetime of the question.
+const extendVoteKeys = `
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	for i = 2, #KEYS do
+		if redis.call('EXISTS', KEYS[i]) == 1 then
+			redis.call('PEXPIRE', KEYS[i], ttl)
+		end
+	end
+end
+`
+
+// voteScript performs dedup, counting and milestone detection in a single
+// round trip so concurrent votes from one user can never be double-counted.
+//
+// KEYS[1] question hash, KEYS[2] votes hash, KEYS[3] legacy voters set,
+// KEYS[4] revealed thresholds set
+// ARGV[1] user ID, ARGV[2] counter field, ARGV[3] alert threshold
+//
+// Returns {status, newly revealed follow-up IDs, question hash as flat list}
+// where status is 0 for a counted vote, 1 for a duplicate, 2 for a changed
+// vote, -1 when the question does not exist and -2 when it is not open. A
+// counted vote appends 1 when it set the alerted field, 0 otherwise.
+var voteScript = redis.NewScript(openPollCheck + `
+if redis.call('SISMEMBER', KEYS[3], ARGV[1]) == 1 then
+	return {1, {}, redis.call('HGETALL', KEYS[1])}
+end
+
+local previous = redis.call('HGET', KEYS[2], ARGV[1])
+if previous then
+	if previous == ARGV[2] or locked then
+		return {1, {}, redis.call('HGETALL', KEYS[1])}
+	end
+	redis.call('HINCRBY', KEYS[1], previous, -1)
+	redis.call('HINCRBY', KEYS[1], ARGV[2], 1)
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+	return {2, {}, redis.call('HGETALL', KEYS[1])}
+end
+
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('HINCRBY', KEYS[1], ARGV[2], 1)
+local total = redis.call('HINCRBY', KEYS[1], 'total_participants', 1)
+
+local revealed = {}
+local milestones = redis.call('HGET', KEYS[1], 'milestones')
+if milestones then
+	for pair in string.gmatch(milestones, '[^,]+') do
+		local threshold, followUp = string.match(pair, '^%s*(%d+)%s*:%s*(.-)%s*$')
+		if threshold and total >= tonumber(threshold) then
+			if redis.call('SADD', KEYS[4], tostring(tonumber(threshold))) == 1 then
+				table.insert(revealed, followUp)
+			end
+		end
+	end
+end
+
+local alerted = 0
+local alertAt = tonumber(ARGV[3])
+if alertAt and alertAt > 0 and total >= alertAt then
+	alerted = redis.call('HSETNX', KEYS[1], 'alerted', '1')
+end
+` + extendVoteKeys + `
+return {0, revealed, redis.call('HGETALL', KEYS[1]), alerted}
+`)
+
+// retractScript removes a user's vote and its counts in a single round trip.
+//
+// KEYS[1] question hash, KEYS[2] votes hash
+// ARGV[1] user ID
+//
+// Returns {status, {}, question hash as flat list} where status is 0 for a
+// retracted vote, -1 when the question does not exist, -2 when it is not
+// open, -3 when votes are locked and -4 when the user has not voted.
+var retractScript = redis.NewScript(openPollCheck + `
+if locked then
+	return {-3, {}, {}}
+end
+local previous = redis.call('HGET', KEYS[2], ARGV[1])
+if not previous then
+	return {-4, {}, {}}
+end
+
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HINCRBY', KEYS[1], previous, -1)
+redis.call('HINCRBY', KEYS[1], 'total_participants', -1)
+return {0, {}, redis.call('HGETALL', KEYS[1])}
+`)
+
+// NewCacheService creates the cache selected by CACHE_DRIVER. The memory
+// driver suits a single instance only: its data is lost on restart and not
+// shared with other instances or the archiver.
+func NewCacheService(cfg config.Config) CacheService {
+	if cfg.RedisConfig.Driver == "memory" {
+		fmt.Println("⚠️ Using the in-memory cache, live polls and sessions will not survive a restart")
+		return NewMemoryCacheService()
+	}
+	return NewRedisCacheService(cfg)
 }
 
 type RedisCacheService struct {
 	rdb *redis.Client
-	ctx context.Context
 }
 
 func NewRedisCacheService(cfg config.Config) *RedisCacheService {
@@ -38,9 +227,7 @@ func NewRedisCacheService(cfg config.Config) *RedisCacheService {
 		DB:       cfg.RedisConfig.DB,       // Use default DB
 	})
 
-	ctx := context.Background()
-
-	if err := rdb.Ping(ctx).Err(); err != nil {
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		panic("❌ Failed to connect to Redis: " + err.Error())
 	}
 
@@ -48,75 +235,197 @@ func NewRedisCacheService(cfg config.Config) *RedisCacheService {
 
 	return &RedisCacheService{
 		rdb: rdb,
-		ctx: ctx,
 	}
 }
 
-func (r *RedisCacheService) Get(key string) (string, error) {
-	val, err := r.rdb.Get(r.ctx, key).Result()
+// unavailable marks an error returned by the Redis client. The original error
+// stays in the chain so context.Canceled and DeadlineExceeded still match.
+func unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrCacheUnavailable, err)
+}
+
+func (r *RedisCacheService) Get(ctx context.Context, key string) (string, error) {
+	val, err := r.rdb.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return "", nil // Key not found
 	}
-	return val, err
+	return val, unavailable(err)
+}
+
+func (r *RedisCacheService) Set(ctx context.Context, key string, value string) error {
+	return unavailable(r.rdb.Set(ctx, key, value, 0).Err())
+}
+
+func (r *RedisCacheService) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return unavailable(r.rdb.Set(ctx, key, value, ttl).Err())
 }
 
-func (r *RedisCacheService) Set(key string, value string) error {
-	return r.rdb.Set(r.ctx, key, value, 0).Err()
+func (r *RedisCacheService) GetAndDelete(ctx context.Context, key string) (string, error) {
+	val, err := r.rdb.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, unavailable(err)
 }
 
-func (r *RedisCacheService) IsSetMember(key, member string) (bool, error) {
-	return r.rdb.SIsMember(r.ctx, key, member).Result()
+func (r *RedisCacheService) IsSetMember(ctx context.Context, key, member string) (bool, error) {
+	ok, err := r.rdb.SIsMember(ctx, key, member).Result()
+	return ok, unavailable(err)
 }
 
-func (r *RedisCacheService) AddSetMember(key, member string) error {
-	return r.rdb.SAdd(r.ctx, key, member).Err()
+func (r *RedisCacheService) AddSetMember(ctx context.Context, key, member string) error {
+	return unavailable(r.rdb.SAdd(ctx, key, member).Err())
 }
 
-func (r *RedisCacheService) IncrementField(key, field string) int64 {
-	val, err := r.rdb.HIncrBy(r.ctx, key, field, 1).Result()
+func (r *RedisCacheService) IncrementField(ctx context.Context, key, field string) (int64, error) {
+	val, err := r.rdb.HIncrBy(ctx, key, field, 1).Result()
 	if err != nil {
-		return 0
+		return 0, unavailable(err)
 	}
-	return val
+	return val, nil
 }
 
-func (r *RedisCacheService) GetField(key, field string) (string, error) {
-	val, err := r.rdb.HGet(r.ctx, key, field).Result()
+func (r *RedisCacheService) GetField(ctx context.Context, key, field string) (string, error) {
+	val, err := r.rdb.HGet(ctx, key, field).Result()
 	if err == redis.Nil {
 		return "", nil
 	}
-	return val, err
+	return val, unavailable(err)
 }
 
-func (r *RedisCacheService) GetFieldInt(key, field string) (int, error) {
-	valStr, err := r.GetField(key, field)
-	if err != nil {
+func (r *RedisCacheService) GetFieldInt(ctx context.Context, key, field string) (int, error) {
+	valStr, err := r.GetField(ctx, key, field)
+	if err != nil || valStr == "" {
 		return 0, err
 	}
-	valInt, _ := strconv.Atoi(valStr)
-	return valInt, nil
+	return strconv.Atoi(valStr)
+}
+
+func (r *RedisCacheService) SetHash(ctx context.Context, key string, data map[string]string) error {
+	return unavailable(r.rdb.HSet(ctx, key, data).Err())
+}
+
+func (r *RedisCacheService) DeleteField(ctx context.Context, key, field string) error {
+	return unavailable(r.rdb.HDel(ctx, key, field).Err())
+}
+
+func (r *RedisCacheService) GetAllHash(ctx context.Context, key string) (map[string]string, error) {
+	data, err := r.rdb.HGetAll(ctx, key).Result()
+	return data, unavailable(err)
+}
+
+func (r *RedisCacheService) AddToSet(ctx context.Context, key, value string) error {
+	return unavailable(r.rdb.SAdd(ctx, key, value).Err())
 }
 
-func (r *RedisCacheService) SetHash(key string, data map[string]string) error {
-	return r.rdb.HSet(r.ctx, key, data).Err()
+func (r *RedisCacheService) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := r.rdb.SMembers(ctx, key).Result()
+	return members, unavailable(err)
 }
 
-func (r *RedisCacheService) GetAllHash(key string) (map[string]string, error) {
-	return r.rdb.HGetAll(r.ctx, key).Result()
+func (r *RedisCacheService) DeleteKey(ctx context.Context, key string) error {
+	return unavailable(r.rdb.Del(ctx, key).Err())
 }
 
-func (r *RedisCacheService) AddToSet(key, value string) error {
-	return r.rdb.SAdd(r.ctx, key, value).Err()
+func (r *RedisCacheService) SetTTL(ctx context.Context, key string, ttl time.Duration) error {
+	return unavailable(r.rdb.Expire(ctx, key, ttl).Err())
 }
 
-func (r *RedisCacheService) GetSetMembers(key string) ([]string, error) {
-	return r.rdb.SMembers(r.ctx, key).Result()
+func (r *RedisCacheService) RecordVote(ctx context.Context, keys VoteKeys, userID, countField string, alertAt int) (VoteResult, error) {
+	raw, err := voteScript.Run(ctx, r.rdb, []string{keys.Question, keys.Votes, keys.LegacyVoters, keys.Revealed}, userID, countField, alertAt).Slice()
+	if err != nil {
+		return VoteResult{}, unavailable(err)
+	}
+	return parseVoteReply(raw)
 }
 
-func (r *RedisCacheService) DeleteKey(key string) error {
-	return r.rdb.Del(r.ctx, key).Err()
+func (r *RedisCacheService) RetractVote(ctx context.Context, keys VoteKeys, userID string) (VoteResult, error) {
+	raw, err := retractScript.Run(ctx, r.rdb, []string{keys.Question, keys.Votes}, userID).Slice()
+	if err != nil {
+		return VoteResult{}, unavailable(err)
+	}
+	return parseVoteReply(raw)
 }
 
-func (r *RedisCacheService) SetTTL(key string, ttl time.Duration) error {
-    return r.rdb.Expire(context.Background(), key, ttl).Err()
-}
\ No newline at end of file
+func parseVoteReply(raw []interface{}) (VoteResult, error) {
+	if len(raw) != 3 && len(raw) != 4 {
+		return VoteResult{}, fmt.Errorf("unexpected vote script reply: %v", raw)
+	}
+
+	status, _ := raw[0].(int64)
+	switch status {
+	case -1:
+		return VoteResult{}, ErrKeyNotFound
+	case -2:
+		return VoteResult{}, ErrVotingClosed
+	case -3:
+		return VoteResult{}, ErrVotesLocked
+	case -4:
+		return VoteResult{}, ErrNoVote
+	}
+
+	result := VoteResult{
+		AlreadyVoted:  status == 1,
+		Changed:       status == 2,
+		NewlyRevealed: []string{},
+		Question:      map[string]string{},
+	}
+	if len(raw) == 4 {
+		alerted, _ := raw[3].(int64)
+		result.ThresholdReached = alerted == 1
+	}
+	revealed, _ := raw[1].([]interface{})
+	for _, id := range revealed {
+		result.NewlyRevealed = append(result.NewlyRevealed, fmt.Sprint(id))
+	}
+	fields, _ := raw[2].([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		result.Question[fmt.Sprint(fields[i])] = fmt.Sprint(fields[i+1])
+	}
+	return result, nil
+}
+
+func (r *RedisCacheService) Publish(ctx context.Context, channel, message string) error {
+	return unavailable(r.rdb.Publish(ctx, channel, message).Err())
+}
+
+func (r *RedisCacheService) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := r.rdb.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed so no message published after
+	// Subscribe returns can be missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, unavailable(err)
+	}
+
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		incoming := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-incoming:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
+func (r *RedisCacheService) Ping(ctx context.Context) error {
+	return unavailable(r.rdb.Ping(ctx).Err())
+}
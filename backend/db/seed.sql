@@ -0,0 +1,51 @@
+-- Local development data. Every user's password is "password123".
+-- Safe to run repeatedly: rows use fixed IDs and are skipped when present.
+
+-- ================================
+-- 1) Users
+-- ================================
+INSERT INTO users (user_id, email, password, role)
+VALUES
+  ('11111111-1111-1111-1111-111111111111', 'alice@example.com',   '$2a$10$u8XqxDC21lN0ZS.ZeamAieAWsw5UIk31E30HXwc/HAoe5s01cAU56', 'admin'),
+  ('22222222-2222-2222-2222-222222222222', 'bob@example.com',     '$2a$10$u8XqxDC21lN0ZS.ZeamAieAWsw5UIk31E30HXwc/HAoe5s01cAU56', 'moderator'),
+  ('33333333-3333-3333-3333-333333333333', 'charlie@example.com', '$2a$10$u8XqxDC21lN0ZS.ZeamAieAWsw5UIk31E30HXwc/HAoe5s01cAU56', 'user')
+ON CONFLICT (user_id) DO NOTHING;
+
+-- ================================
+-- 2) Archived questions
+-- ================================
+INSERT INTO questions (
+  question_id,
+  archive_date,
+  question_text,
+  first_choice,
+  second_choice,
+  total_participants,
+  first_choice_count,
+  second_choice_count,
+  margin_of_victory,
+  created_by
+)
+VALUES
+  ('aaaaaaaa-0000-0000-0000-000000000001', '2025-03-24', 'Do you like pineapple on pizza?',
+   'Yes', 'No', 50, 30, 20, 10, '11111111-1111-1111-1111-111111111111'),
+  ('aaaaaaaa-0000-0000-0000-000000000002', '2025-03-25', 'Are you a morning person?',
+   'Yes', 'No', 60, 45, 15, 30, '22222222-2222-2222-2222-222222222222'),
+  ('aaaaaaaa-0000-0000-0000-000000000003', CURRENT_DATE - 1, 'Which language do you reach for first?',
+   'Go', 'Python', 70, 30, 25, 5, '11111111-1111-1111-1111-111111111111')
+ON CONFLICT (question_id) DO NOTHING;
+
+-- ================================
+-- 3) Options (positions 0 and 1 mirror first/second choice)
+-- ================================
+INSERT INTO question_options (question_id, position, option_text, vote_count)
+VALUES
+  ('aaaaaaaa-0000-0000-0000-000000000001', 0, 'Yes', 30),
+  ('aaaaaaaa-0000-0000-0000-000000000001', 1, 'No', 20),
+  ('aaaaaaaa-0000-0000-0000-000000000002', 0, 'Yes', 45),
+  ('aaaaaaaa-0000-0000-0000-000000000002', 1, 'No', 15),
+  ('aaaaaaaa-0000-0000-0000-000000000003', 0, 'Go', 30),
+  ('aaaaaaaa-0000-0000-0000-000000000003', 1, 'Python', 25),
+  ('aaaaaaaa-0000-0000-0000-000000000003', 2, 'TypeScript', 10),
+  ('aaaaaaaa-0000-0000-0000-000000000003', 3, 'Rust', 5)
+ON CONFLICT (question_id, position) DO NOTHING;
@@ -3,4 +3,25 @@ package entity
 type HealthCheckResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
+
+// Readiness statuses. A failed critical dependency makes the whole response
+// unavailable; a failed optional one only degrades it.
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// DependencyStatus is the outcome of checking one dependency. The endpoint is
+// public, so the error behind a failure is only logged.
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type ReadinessResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
@@ -1,27 +1,121 @@
 package entity
 
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
 type CreateQuestionCacheRequest struct {
-	Text         string `json:"text"`
-	FirstChoice  string `json:"first_choice"`
-	SecondChoice string `json:"second_choice"`
-	Milestones   string `json:"milestones"` // like "100:id1,150:id2"
-	FollowUps    string `json:"follow_ups"` // optional
-	GroupID      string `json:"group_id"`   // optional
-	UserID       string `json:"user_id"`    // Injected in controller from JWT
-}
-
-type QuestionCache struct {
-	QuestionID        string `json:"question_id"`
-	GroupID           string `json:"group_id"`
-	FirstChoice       string `json:"first_choice"`
-	FirstChoiceCount  int    `json:"first_choice_count"`
-	FollowUps         string `json:"follow_ups"`
-	Milestones        string `json:"milestones"`
-	TotalParticipants int    `json:"total_participants"`
-	SecondChoice      string `json:"second_choice"`
-	SecondChoiceCount int    `json:"second_choice_count"`
-	Text              string `json:"text"`
-	UserID            string `json:"user_id"`
+	Text         string     `json:"text"`
+	FirstChoice  string     `json:"first_choice"`  // legacy two-choice clients
+	SecondChoice string     `json:"second_choice"` // legacy two-choice clients
+	Options      []string   `json:"options"`       // 2-10 choices, takes precedence over first/second
+	Milestones   Milestones `json:"milestones"`
+	GroupID      string     `json:"group_id"` // optional
+	UserID       string     `json:"user_id"`  // Injected in controller from JWT
+	// FollowUp holds the poll until a milestone of another poll opens it.
+	// Follow-ups run until the end of the day they open and cannot set
+	// OpensAt or ClosesAt.
+	FollowUp bool `json:"follow_up"`
+	// OpensAt defaults to now; a later time schedules the poll.
+	OpensAt *time.Time `json:"opens_at,omitempty"`
+	// ClosesAt defaults to the end of the day the poll opens and may be at
+	// most 24 hours after OpensAt.
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+	// Timezone is the IANA zone the poll's day is counted in. Defaults to APP_TIMEZONE.
+	Timezone string `json:"timezone,omitempty"`
+	// LockVotes makes votes final; otherwise voters may change or retract
+	// their vote until the poll closes.
+	LockVotes bool `json:"lock_votes"`
+}
+
+type CreateQuestionCacheResponse struct {
+	QuestionID string    `json:"question_id"`
+	OpensAt    time.Time `json:"opens_at"`
+	ClosesAt   time.Time `json:"closes_at"`
+	Scheduled  bool      `json:"scheduled"` // true until the poll opens
+	FollowUp   bool      `json:"follow_up"` // held until a milestone opens it
+}
+
+// Milestones of a new poll. Besides a list of objects it accepts the legacy
+// "100:id1,150:id2" string.
+type Milestones []model.Milestone
+
+func (m *Milestones) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, (*[]model.Milestone)(m))
+	}
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := util.ParseMilestones(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Follow-up statuses reported by GET /question/cache/:id/follow-ups.
+const (
+	FollowUpLocked   = "locked"   // the threshold has not been reached
+	FollowUpPending  = "pending"  // the threshold was reached and the poll is about to open
+	FollowUpUnlocked = "unlocked" // the poll is live
+)
+
+type FollowUpStatus struct {
+	Threshold  int    `json:"threshold"`
+	FollowUpID string `json:"follow_up_id"`
+	Status     string `json:"status"`
+}
+
+type FollowUpsResponse struct {
+	QuestionID        string           `json:"question_id"`
+	TotalParticipants int              `json:"total_participants"`
+	FollowUps         []FollowUpStatus `json:"follow_ups"`
+}
+
+// QuestionPage is one page of GET /question/. NextCursor is nil on the last
+// page.
+type QuestionPage struct {
+	Questions  []model.Question `json:"questions"`
+	NextCursor *string          `json:"next_cursor"`
+}
+
+// QuestionSearchResult is the response of GET /question/search. Both lists
+// are ordered best match first.
+type QuestionSearchResult struct {
+	Live     []model.QuestionCache `json:"live"`
+	Archived []model.Question      `json:"archived"`
+}
+
+// VoteHistory is one page of GET /user/me/votes. NextOffset is nil on the
+// last page.
+type VoteHistory struct {
+	Votes      []model.Vote `json:"votes"`
+	NextOffset *int         `json:"next_offset"`
+}
+
+// ScheduledQuestion is a poll that has not opened yet.
+type ScheduledQuestion struct {
+	QuestionID string    `json:"question_id"`
+	Text       string    `json:"text"`
+	Options    []string  `json:"options"`
+	OpensAt    time.Time `json:"opens_at"`
+	ClosesAt   time.Time `json:"closes_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// QuestionOption is a single choice of a poll together with its tally.
+type QuestionOption struct {
+	OptionID string `json:"option_id"`
+	Text     string `json:"text"`
+	Count    int    `json:"count"`
 }
 
 type CreateQuestionRequest struct {
@@ -32,20 +126,31 @@ type CreateQuestionRequest struct {
 	TotalParticipants int    `json:"total_participants"`
 	FirstChoiceCount  int    `json:"first_choice_count"`
 	SecondChoiceCount int    `json:"second_choice_count"`
-	CreatedBy         string `json:"created_by"`
+	CreatedBy         string `json:"created_by"` // optional, must match the JWT subject
+	// Options replaces first/second choice for polls with more than two choices.
+	Options []QuestionOption `json:"options"`
 }
 
 type VoteRequest struct {
-	UserID        string `json:"user_id"`
+	UserID        string `json:"user_id"` // optional, must match the JWT subject
 	QuestionID    string `json:"question_id"`
-	IsFirstChoice bool   `json:"is_first_choice"`
+	OptionID      string `json:"option_id"`
+	IsFirstChoice bool   `json:"is_first_choice"` // used when option_id is empty
 }
 
 type VoteResponse struct {
-	QuestionID        string   `json:"question_id"`
-	TotalParticipants int      `json:"total_participants"`
-	FirstChoiceCount  int      `json:"first_choice_count"`
-	SecondChoiceCount int      `json:"second_choice_count"`
-	NewlyRevealedIDs  []string `json:"newly_revealed_ids"`
-	AlreadyVoted      bool     `json:"already_voted"`
+	QuestionID        string           `json:"question_id"`
+	TotalParticipants int              `json:"total_participants"`
+	FirstChoiceCount  int              `json:"first_choice_count"`
+	SecondChoiceCount int              `json:"second_choice_count"`
+	Options           []QuestionOption `json:"options"`
+	NewlyRevealedIDs  []string         `json:"newly_revealed_ids"`
+	AlreadyVoted      bool             `json:"already_voted"`
+	Changed           bool             `json:"changed"` // an earlier vote moved to this option
+}
+
+// StreamTicketResponse carries a single-use ticket for GET /question/:id/stream.
+type StreamTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"` // seconds
 }
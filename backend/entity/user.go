@@ -1,5 +1,11 @@
 package entity
 
+// Actor is the authenticated caller on whose behalf a service call is made.
+type Actor struct {
+	UserID string
+	Role   string
+}
+
 type RegisterRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -10,6 +16,10 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
 type UpdateUserRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -0,0 +1,41 @@
+package testfakes
+
+import (
+	"context"
+
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+)
+
+// FailingCache wraps a working cache and fails the calls whose error is set.
+type FailingCache struct {
+	db.CacheService
+	SetHashErr    error
+	GetAllHashErr error
+	RecordVoteErr error
+}
+
+// NewFailingCache wraps an in-memory cache.
+func NewFailingCache() *FailingCache {
+	return &FailingCache{CacheService: db.NewMemoryCacheService()}
+}
+
+func (c *FailingCache) SetHash(ctx context.Context, key string, data map[string]string) error {
+	if c.SetHashErr != nil {
+		return c.SetHashErr
+	}
+	return c.CacheService.SetHash(ctx, key, data)
+}
+
+func (c *FailingCache) GetAllHash(ctx context.Context, key string) (map[string]string, error) {
+	if c.GetAllHashErr != nil {
+		return nil, c.GetAllHashErr
+	}
+	return c.CacheService.GetAllHash(ctx, key)
+}
+
+func (c *FailingCache) RecordVote(ctx context.Context, keys db.VoteKeys, userID, countField string, alertAt int) (db.VoteResult, error) {
+	if c.RecordVoteErr != nil {
+		return db.VoteResult{}, c.RecordVoteErr
+	}
+	return c.CacheService.RecordVote(ctx, keys, userID, countField, alertAt)
+}
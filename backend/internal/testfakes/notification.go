@@ -0,0 +1,71 @@
+package testfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+)
+
+// ParticipantsAlert is one call to SendAlertReachParticipantsToAdmin.
+type ParticipantsAlert struct {
+	QuestionText      string
+	TotalParticipants int
+}
+
+// NotificationService records the notifications a service queues.
+type NotificationService struct {
+	mu                sync.Mutex
+	ParticipantAlerts []ParticipantsAlert
+	UserAlerts        []entity.Alert
+	Err               error
+}
+
+func (n *NotificationService) SendAlertReachParticipantsToAdmin(ctx context.Context, questionText string, totalParticipants int, options []entity.QuestionOption) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ParticipantAlerts = append(n.ParticipantAlerts, ParticipantsAlert{questionText, totalParticipants})
+	return n.Err
+}
+
+func (n *NotificationService) NotifyUserOfAdminQuestion(ctx context.Context, email, subject, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.UserAlerts = append(n.UserAlerts, entity.Alert{Subject: subject, Message: message})
+	return n.Err
+}
+
+func (n *NotificationService) Deliver(ctx context.Context, msg model.OutboxMessage) error {
+	return n.Err
+}
+
+// NotificationRepo stands in for SNS and records what it was asked to send.
+type NotificationRepo struct {
+	mu            sync.Mutex
+	AdminAlerts   []entity.Alert
+	UserAlerts    []entity.Alert
+	Subscriptions []string
+	Err           error
+}
+
+func (r *NotificationRepo) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.AdminAlerts = append(r.AdminAlerts, alert)
+	return r.Err
+}
+
+func (r *NotificationRepo) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.UserAlerts = append(r.UserAlerts, alert)
+	return r.Err
+}
+
+func (r *NotificationRepo) SubscribeToUserTopic(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Subscriptions = append(r.Subscriptions, email)
+	return r.Err
+}
@@ -0,0 +1,534 @@
+// Package testfakes holds in-memory stand-ins for the repositories, cache and
+// notification service, shared by the service and controller tests. Each
+// fake's Err, when set, fails every call to it.
+package testfakes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"gorm.io/gorm"
+)
+
+// QuestionRepo keeps archived questions in memory.
+type QuestionRepo struct {
+	mu        sync.Mutex
+	Questions map[uuid.UUID]model.Question
+	Outbox    []model.OutboxMessage
+	Err       error
+	archiving bool
+}
+
+func NewQuestionRepo(questions ...model.Question) *QuestionRepo {
+	r := &QuestionRepo{Questions: map[uuid.UUID]model.Question{}}
+	for _, q := range questions {
+		r.Questions[q.QuestionID] = q
+	}
+	return r
+}
+
+func (r *QuestionRepo) CreateQuestion(ctx context.Context, q model.Question, outbox ...model.OutboxMessage) (model.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return model.Question{}, r.Err
+	}
+	if q.QuestionID == uuid.Nil {
+		q.QuestionID = uuid.New()
+	}
+	r.Questions[q.QuestionID] = q
+	r.Outbox = append(r.Outbox, outbox...)
+	return q, nil
+}
+
+func (r *QuestionRepo) FindByID(ctx context.Context, id uuid.UUID) (model.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return model.Question{}, r.Err
+	}
+	q, ok := r.Questions[id]
+	if !ok {
+		return model.Question{}, gorm.ErrRecordNotFound
+	}
+	return q, nil
+}
+
+func (r *QuestionRepo) FindPage(ctx context.Context, filter repository.QuestionFilter) ([]model.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var questions []model.Question
+	for _, q := range r.Questions {
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+func (r *QuestionRepo) Search(ctx context.Context, query string, limit int) ([]model.Question, error) {
+	return nil, r.Err
+}
+
+func (r *QuestionRepo) DeleteQuestion(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	if _, ok := r.Questions[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.Questions, id)
+	return nil
+}
+
+func (r *QuestionRepo) FindLastArchivedQuestion(ctx context.Context) (model.Question, error) {
+	return model.Question{}, gorm.ErrRecordNotFound
+}
+
+func (r *QuestionRepo) UpsertQuestion(ctx context.Context, q model.Question) (model.Question, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return model.Question{}, r.Err
+	}
+	r.Questions[q.QuestionID] = q
+	return q, nil
+}
+
+func (r *QuestionRepo) LockArchive(ctx context.Context) (func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.archiving {
+		return nil, repository.ErrArchiveLocked
+	}
+	r.archiving = true
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.archiving = false
+	}, nil
+}
+
+// ScheduledRepo keeps scheduled and held polls in memory.
+type ScheduledRepo struct {
+	mu        sync.Mutex
+	Questions map[uuid.UUID]model.ScheduledQuestion
+	Err       error
+}
+
+func NewScheduledRepo() *ScheduledRepo {
+	return &ScheduledRepo{Questions: map[uuid.UUID]model.ScheduledQuestion{}}
+}
+
+func (r *ScheduledRepo) CreateScheduledQuestion(ctx context.Context, sq model.ScheduledQuestion) (model.ScheduledQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return model.ScheduledQuestion{}, r.Err
+	}
+	sq.CreatedAt = time.Now()
+	r.Questions[sq.QuestionID] = sq
+	return sq, nil
+}
+
+func (r *ScheduledRepo) FindUpcomingByUser(ctx context.Context, userID uuid.UUID) ([]model.ScheduledQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var upcoming []model.ScheduledQuestion
+	for _, sq := range r.Questions {
+		if sq.UserID == userID && sq.Status == model.ScheduleStatusPending {
+			upcoming = append(upcoming, sq)
+		}
+	}
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i].OpensAt.Before(upcoming[j].OpensAt) })
+	return upcoming, r.Err
+}
+
+func (r *ScheduledRepo) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.ScheduledQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []model.ScheduledQuestion
+	for id, sq := range r.Questions {
+		waiting := sq.Status == model.ScheduleStatusHeld || sq.Status == model.ScheduleStatusPending
+		if waiting && !sq.ClosesAt.After(now) {
+			sq.Status = model.ScheduleStatusExpired
+			r.Questions[id] = sq
+			continue
+		}
+		claimed := sq.ClaimedUntil != nil && sq.ClaimedUntil.After(now)
+		if sq.Status == model.ScheduleStatusPending && !sq.OpensAt.After(now) && !claimed && len(due) < limit {
+			claimedUntil := now.Add(lease)
+			sq.ClaimedUntil = &claimedUntil
+			r.Questions[id] = sq
+			due = append(due, sq)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].OpensAt.Before(due[j].OpensAt) })
+	return due, nil
+}
+
+func (r *ScheduledRepo) MarkActive(ctx context.Context, id uuid.UUID, activatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sq, ok := r.Questions[id]
+	if !ok || sq.Status != model.ScheduleStatusPending {
+		return nil
+	}
+	sq.Status = model.ScheduleStatusActive
+	sq.ActivatedAt = &activatedAt
+	sq.ClaimedUntil = nil
+	r.Questions[id] = sq
+	return nil
+}
+
+func (r *ScheduledRepo) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.ScheduledQuestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var found []model.ScheduledQuestion
+	for _, id := range ids {
+		if sq, ok := r.Questions[id]; ok {
+			found = append(found, sq)
+		}
+	}
+	return found, nil
+}
+
+func (r *ScheduledRepo) ReleaseHeld(ctx context.Context, id uuid.UUID, opensAt, closesAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sq, ok := r.Questions[id]
+	if !ok || sq.Status != model.ScheduleStatusHeld {
+		return false, nil
+	}
+	sq.Status = model.ScheduleStatusPending
+	sq.OpensAt = opensAt
+	sq.ClosesAt = closesAt
+	r.Questions[id] = sq
+	return true, nil
+}
+
+func (r *ScheduledRepo) HoldUntil(ctx context.Context, ids []uuid.UUID, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		if sq, ok := r.Questions[id]; ok && sq.Status == model.ScheduleStatusHeld && sq.ClosesAt.Before(until) {
+			sq.ClosesAt = until
+			r.Questions[id] = sq
+		}
+	}
+	return nil
+}
+
+// VoteRepo records the vote history written behind Redis.
+type VoteRepo struct {
+	mu    sync.Mutex
+	Votes map[VoteKey]model.Vote
+	Err   error
+}
+
+// VoteKey is the primary key of the votes table.
+type VoteKey struct {
+	UserID     uuid.UUID
+	QuestionID uuid.UUID
+}
+
+func NewVoteRepo() *VoteRepo {
+	return &VoteRepo{Votes: map[VoteKey]model.Vote{}}
+}
+
+// ForQuestion returns the votes stored for questionID in any order.
+func (r *VoteRepo) ForQuestion(questionID string) []model.Vote {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var votes []model.Vote
+	for key, v := range r.Votes {
+		if key.QuestionID.String() == questionID {
+			votes = append(votes, v)
+		}
+	}
+	return votes
+}
+
+func (r *VoteRepo) UpsertVote(ctx context.Context, v model.Vote) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	key := VoteKey{v.UserID, v.QuestionID}
+	if existing, ok := r.Votes[key]; ok && !existing.VotedAt.Before(v.VotedAt) {
+		return nil
+	}
+	r.Votes[key] = v
+	return nil
+}
+
+func (r *VoteRepo) DeleteVote(ctx context.Context, userID, questionID uuid.UUID, votedBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	key := VoteKey{userID, questionID}
+	if existing, ok := r.Votes[key]; ok && !existing.VotedAt.After(votedBefore) {
+		delete(r.Votes, key)
+	}
+	return nil
+}
+
+func (r *VoteRepo) ReplaceVotes(ctx context.Context, questionID uuid.UUID, votes []model.Vote) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	previous := map[VoteKey]model.Vote{}
+	for key, v := range r.Votes {
+		if key.QuestionID == questionID {
+			previous[key] = v
+			delete(r.Votes, key)
+		}
+	}
+	for _, v := range votes {
+		key := VoteKey{v.UserID, v.QuestionID}
+		if old, ok := previous[key]; ok {
+			v.VotedAt = old.VotedAt
+		}
+		r.Votes[key] = v
+	}
+	return nil
+}
+
+func (r *VoteRepo) FindByUser(ctx context.Context, userID uuid.UUID, filter repository.VoteFilter) ([]model.Vote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var votes []model.Vote
+	for _, v := range r.Votes {
+		if v.UserID == userID {
+			votes = append(votes, v)
+		}
+	}
+	return votes, r.Err
+}
+
+// UserRepo keeps users in memory. FindErr, CreateErr and UpdateErr fail the
+// matching calls.
+type UserRepo struct {
+	mu        sync.Mutex
+	Users     map[string]model.User // keyed by user ID
+	Outbox    []model.OutboxMessage
+	FindErr   error
+	CreateErr error
+	UpdateErr error
+}
+
+func NewUserRepo(users ...model.User) *UserRepo {
+	r := &UserRepo{Users: map[string]model.User{}}
+	for _, u := range users {
+		r.Users[u.UserID.String()] = u
+	}
+	return r
+}
+
+func (r *UserRepo) CreateUser(ctx context.Context, u model.User, outbox ...model.OutboxMessage) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.CreateErr != nil {
+		return model.User{}, r.CreateErr
+	}
+	u.UserID = uuid.New()
+	u.CreatedAt = time.Now()
+	r.Users[u.UserID.String()] = u
+	r.Outbox = append(r.Outbox, outbox...)
+	return u, nil
+}
+
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.FindErr != nil {
+		return model.User{}, r.FindErr
+	}
+	for _, u := range r.Users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return model.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, id string) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.FindErr != nil {
+		return model.User{}, r.FindErr
+	}
+	u, ok := r.Users[id]
+	if !ok {
+		return model.User{}, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+func (r *UserRepo) UpdateUser(ctx context.Context, u model.User) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.UpdateErr != nil {
+		return model.User{}, r.UpdateErr
+	}
+	r.Users[u.UserID.String()] = u
+	return u, nil
+}
+
+func (r *UserRepo) DeleteUser(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Users, id)
+	return nil
+}
+
+// OutboxRepo keeps enqueued notifications in memory and leases, retries and
+// requeues them the way the Postgres outbox does.
+type OutboxRepo struct {
+	mu       sync.Mutex
+	Messages []model.OutboxMessage
+	Err      error
+}
+
+func (r *OutboxRepo) Enqueue(ctx context.Context, msgs ...model.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	for _, msg := range msgs {
+		msg.ID = uuid.New()
+		msg.Status = model.OutboxStatusPending
+		if msg.NextAttemptAt.IsZero() {
+			msg.NextAttemptAt = time.Now()
+		}
+		r.Messages = append(r.Messages, msg)
+	}
+	return nil
+}
+
+func (r *OutboxRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	now := time.Now()
+	var due []int
+	for i, msg := range r.Messages {
+		if msg.Status == model.OutboxStatusPending && !msg.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool {
+		return r.Messages[due[a]].NextAttemptAt.Before(r.Messages[due[b]].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]model.OutboxMessage, 0, len(due))
+	for _, i := range due {
+		r.Messages[i].Attempts++
+		r.Messages[i].NextAttemptAt = now.Add(lease)
+		claimed = append(claimed, r.Messages[i])
+	}
+	return claimed, nil
+}
+
+func (r *OutboxRepo) MarkSent(ctx context.Context, id uuid.UUID) error {
+	return r.update(id, func(msg *model.OutboxMessage) {
+		now := time.Now()
+		msg.Status = model.OutboxStatusSent
+		msg.SentAt = &now
+		msg.LastError = ""
+	})
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	return r.update(id, func(msg *model.OutboxMessage) {
+		msg.Status = model.OutboxStatusPending
+		if dead {
+			msg.Status = model.OutboxStatusDead
+		}
+		msg.LastError = lastError
+		msg.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r *OutboxRepo) FindByStatus(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var found []model.OutboxMessage
+	for _, msg := range r.Messages {
+		if msg.Status == status && len(found) < limit {
+			found = append(found, msg)
+		}
+	}
+	return found, nil
+}
+
+func (r *OutboxRepo) Requeue(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	for i := range r.Messages {
+		if r.Messages[i].ID == id && r.Messages[i].Status == model.OutboxStatusDead {
+			r.Messages[i].Status = model.OutboxStatusPending
+			r.Messages[i].Attempts = 0
+			r.Messages[i].NextAttemptAt = time.Now()
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// update applies fn to the message with id. Like an UPDATE, it does nothing
+// when no message matches.
+func (r *OutboxRepo) update(id uuid.UUID, fn func(*model.OutboxMessage)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	for i := range r.Messages {
+		if r.Messages[i].ID == id {
+			fn(&r.Messages[i])
+		}
+	}
+	return nil
+}
+
+// HealthCheckRepo stands in for the Postgres ping; set Err to take the
+// database down.
+type HealthCheckRepo struct {
+	Err error
+}
+
+func (r *HealthCheckRepo) PingDatabase(ctx context.Context) error {
+	return r.Err
+}
@@ -19,6 +19,7 @@ type LoggerInterface interface {
 	ErrorWithID(ctx context.Context, args ...interface{})
 	DebugWithID(ctx context.Context, args ...interface{})
 	InfoWithID(ctx context.Context, args ...interface{})
+	AuditWithID(ctx context.Context, event string, keysAndValues ...interface{})
 }
 
 // Logger wraps zap.SugaredLogger and implements LoggerInterface
@@ -97,6 +98,14 @@ func (l *Logger) InfoWithID(ctx context.Context, args ...interface{}) {
 	loggerWithSkip.With(fields...).Info(args...)
 }
 
+// AuditWithID records a security-relevant event as a structured warning tagged
+// with audit=true so it can be filtered out of the regular application logs.
+func (l *Logger) AuditWithID(ctx context.Context, event string, keysAndValues ...interface{}) {
+	loggerWithSkip := l.SugaredLogger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar()
+	fields := append(l.buildLogFields(ctx), "audit", true, "event", event)
+	loggerWithSkip.With(fields...).Warnw("[Audit] "+event, keysAndValues...)
+}
+
 // buildLogFields extracts and builds log fields from context and log type
 func (l *Logger) buildLogFields(ctx context.Context) []interface{} {
 	// Example implementation: extract information from context keys
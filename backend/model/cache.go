@@ -1,15 +1,37 @@
 package model
 
+import "time"
+
 type QuestionCache struct {
-	QuestionID        string `json:"question_id"`
-	UserID            string `json:"user_id"`
-	Text              string `json:"text"`
-	FirstChoice       string `json:"first_choice"`
-	SecondChoice      string `json:"second_choice"`
-	TotalParticipants int    `json:"total_participants"`
-	FirstChoiceCount  int    `json:"first_choice_count"`
-	SecondChoiceCount int    `json:"second_choice_count"`
-	Milestones        string `json:"milestones"` // like "100:id1,150:id2"
-	FollowUps         string `json:"follow_ups"` // optional
-	GroupID           string `json:"group_id"`   // for grouping related questions
-}
\ No newline at end of file
+	QuestionID        string                `json:"question_id"`
+	UserID            string                `json:"user_id"`
+	Text              string                `json:"text"`
+	FirstChoice       string                `json:"first_choice"`
+	SecondChoice      string                `json:"second_choice"`
+	TotalParticipants int                   `json:"total_participants"`
+	FirstChoiceCount  int                   `json:"first_choice_count"`
+	SecondChoiceCount int                   `json:"second_choice_count"`
+	Milestones        string                `json:"milestones"` // like "100:id1,150:id2"
+	FollowUps         string                `json:"follow_ups"` // follow-up IDs of Milestones, comma separated
+	GroupID           string                `json:"group_id"`   // for grouping related questions
+	Timezone          string                `json:"timezone,omitempty"`
+	OpensAt           *time.Time            `json:"opens_at,omitempty"`
+	ClosesAt          *time.Time            `json:"closes_at,omitempty"`
+	IsOpen            bool                  `json:"is_open"`
+	LockVotes         bool                  `json:"lock_votes"`
+	MyVote            string                `json:"my_vote,omitempty"` // option ID the caller voted for
+	Options           []QuestionCacheOption `json:"options"`
+}
+
+type QuestionCacheOption struct {
+	OptionID string `json:"option_id"`
+	Text     string `json:"text"`
+	Count    int    `json:"count"`
+}
+
+// Milestone opens the follow-up poll FollowUpID once a poll reaches Threshold
+// participants.
+type Milestone struct {
+	Threshold  int    `json:"threshold"`
+	FollowUpID string `json:"follow_up_id"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox message kinds.
+const (
+	OutboxKindAdminAlert    = "admin_alert"
+	OutboxKindUserAlert     = "user_alert"
+	OutboxKindSubscribeUser = "subscribe_user"
+)
+
+// Outbox message statuses.
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxMessage is a notification waiting in notification_outbox to be
+// delivered. Payload is the JSON encoding of the kind's arguments.
+type OutboxMessage struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Kind          string     `json:"kind" gorm:"type:varchar(50);not null"`
+	Payload       string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	LastError     string     `json:"last_error" gorm:"not null;default:''"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "notification_outbox"
+}
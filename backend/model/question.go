@@ -1,19 +1,73 @@
 package model
 
 import (
-	"time"
 	"github.com/google/uuid"
+	"time"
 )
 
 type Question struct {
-	QuestionID         uuid.UUID `json:"question_id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	ArchiveDate        time.Time `json:"archive_date" gorm:"type:date;not null"`
-	QuestionText       string    `json:"question_text" gorm:"type:varchar(255);not null"`
-	FirstChoice        string    `json:"first_choice"  gorm:"type:varchar(255);not null"`
-	SecondChoice       string    `json:"second_choice" gorm:"type:varchar(255);not null"`
-	TotalParticipants  int       `json:"total_participants" gorm:"not null;default:0"`
-	FirstChoiceCount   int       `json:"first_choice_count" gorm:"not null;default:0"`
-	SecondChoiceCount  int       `json:"second_choice_count" gorm:"not null;default:0"`
-	CreatedBy          uuid.UUID `json:"created_by" gorm:"type:uuid;"`
-	CreatedAt          time.Time `json:"created_at" gorm:"autoCreateTime"`
+	QuestionID        uuid.UUID        `json:"question_id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ArchiveDate       time.Time        `json:"archive_date" gorm:"type:date;not null"`
+	QuestionText      string           `json:"question_text" gorm:"type:varchar(255);not null"`
+	FirstChoice       string           `json:"first_choice"  gorm:"type:varchar(255);not null"`
+	SecondChoice      string           `json:"second_choice" gorm:"type:varchar(255);not null"`
+	TotalParticipants int              `json:"total_participants" gorm:"not null;default:0"`
+	FirstChoiceCount  int              `json:"first_choice_count" gorm:"not null;default:0"`
+	SecondChoiceCount int              `json:"second_choice_count" gorm:"not null;default:0"`
+	MarginOfVictory   int              `json:"margin_of_victory" gorm:"not null;default:0"`
+	CreatedBy         uuid.UUID        `json:"created_by" gorm:"type:uuid;"`
+	OpensAt           *time.Time       `json:"opens_at,omitempty"`
+	ClosesAt          *time.Time       `json:"closes_at,omitempty"`
+	CreatedAt         time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	Options           []QuestionOption `json:"options" gorm:"foreignKey:QuestionID;references:QuestionID"`
+}
+
+// MarginOfVictory returns how many votes the leading option is ahead of the
+// runner-up.
+func MarginOfVictory(options []QuestionOption) int {
+	first, second := 0, 0
+	for _, option := range options {
+		switch {
+		case option.VoteCount > first:
+			first, second = option.VoteCount, first
+		case option.VoteCount > second:
+			second = option.VoteCount
+		}
+	}
+	return first - second
+}
+
+// QuestionOption is one choice of a question. The first two options mirror
+// FirstChoice and SecondChoice so two-choice clients keep working.
+type QuestionOption struct {
+	OptionID   uuid.UUID `json:"option_id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	QuestionID uuid.UUID `json:"question_id" gorm:"type:uuid;not null;index"`
+	Position   int       `json:"position" gorm:"not null"`
+	OptionText string    `json:"option_text" gorm:"type:varchar(255);not null"`
+	VoteCount  int       `json:"vote_count" gorm:"not null;default:0"`
+}
+
+// Scheduled question statuses. Held follow-ups become pending once a milestone
+// releases them and expire once no poll that could release them is open.
+const (
+	ScheduleStatusHeld    = "held"
+	ScheduleStatusPending = "pending"
+	ScheduleStatusActive  = "active"
+	ScheduleStatusExpired = "expired"
+)
+
+// ScheduledQuestion is a poll waiting for its opens_at. Payload is the JSON
+// encoding of the entity.CreateQuestionCacheRequest it was created from.
+type ScheduledQuestion struct {
+	QuestionID   uuid.UUID  `json:"question_id" gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	QuestionText string     `json:"question_text" gorm:"type:varchar(255);not null"`
+	Payload      string     `json:"-" gorm:"type:jsonb;not null"`
+	OpensAt      time.Time  `json:"opens_at" gorm:"not null"`
+	ClosesAt     time.Time  `json:"closes_at" gorm:"not null"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	ActivatedAt  *time.Time `json:"activated_at"`
+	// ClaimedUntil is when the scheduler's lease on a pending poll runs out.
+	ClaimedUntil *time.Time `json:"-"`
 }
@@ -9,5 +9,6 @@ type User struct {
     UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
     Email     string    `json:"email" gorm:"unique;not null"`
     Password  string    `json:"password" gorm:"not null"`
+    Role      string    `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
     CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
\ No newline at end of file
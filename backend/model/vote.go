@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vote is the option a user currently backs in a poll. OptionID is the
+// option's ID in Redis, or first_choice/second_choice for two-choice polls.
+type Vote struct {
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	QuestionID   uuid.UUID `json:"question_id" gorm:"type:uuid;primaryKey"`
+	OptionID     string    `json:"option_id" gorm:"type:varchar(64);not null"`
+	OptionText   string    `json:"option_text" gorm:"type:varchar(255);not null"`
+	QuestionText string    `json:"question_text" gorm:"type:varchar(255);not null"`
+	PollDate     time.Time `json:"poll_date" gorm:"type:date;not null"`
+	VotedAt      time.Time `json:"voted_at" gorm:"not null"`
+}
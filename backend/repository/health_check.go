@@ -1 +1,39 @@
-package repository
\ No newline at end of file
+package repository
+
+import (
+	"context"
+
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"gorm.io/gorm"
+)
+
+// HealthCheckRepository reports whether the database can serve requests.
+type HealthCheckRepository interface {
+	PingDatabase(ctx context.Context) error
+}
+
+type healthCheckRepository struct {
+	db  *gorm.DB
+	log log.LoggerInterface
+}
+
+// NewHealthCheckRepository creates a new healthCheckRepository with injected DB and logger.
+func NewHealthCheckRepository(db *gorm.DB, logger log.LoggerInterface) HealthCheckRepository {
+	return &healthCheckRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (r *healthCheckRepository) PingDatabase(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		r.log.ErrorWithID(ctx, "[Repository: PingDatabase] Error getting connection pool:", err)
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		r.log.ErrorWithID(ctx, "[Repository: PingDatabase] Error pinging database:", err)
+		return err
+	}
+	return nil
+}
@@ -2,115 +2,113 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
 	cfg "github.com/guncv/Poll-Voting-Website/backend/config"
 	"github.com/guncv/Poll-Voting-Website/backend/entity"
 	"github.com/guncv/Poll-Voting-Website/backend/log"
 )
 
-type NotificationRepository struct {
-	client *sns.Client
-	cfg    cfg.Config
-	log    log.LoggerInterface
-}
-
+// INotificationRepository delivers alerts to admins and to subscribed users.
 type INotificationRepository interface {
 	SendAdminAlert(ctx context.Context, alert entity.Alert) error
 	SendUserAlert(ctx context.Context, alert entity.Alert) error
 	SubscribeToUserTopic(ctx context.Context, email string) error
-	GetAdminSubscriptions(ctx context.Context) ([]string, error)
 }
 
-func NewNotificationRepository(client *sns.Client, cfg cfg.Config, logger log.LoggerInterface) INotificationRepository {
-	return &NotificationRepository{
-		client: client,
-		cfg:    cfg,
-		log:    logger,
+// Notification channels accepted in NOTIFICATION_CHANNELS.
+const (
+	ChannelSNS     = "sns"
+	ChannelSMTP    = "smtp"
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelLog     = "log"
+)
+
+// NewNotificationRepository builds the channels listed in
+// NOTIFICATION_CHANNELS (comma-separated). With more than one channel every
+// alert fans out to all of them.
+func NewNotificationRepository(cfg cfg.Config, logger log.LoggerInterface) (INotificationRepository, error) {
+	var channels []INotificationRepository
+	var names []string
+	for _, name := range strings.Split(cfg.Notification.Channels, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		var channel INotificationRepository
+		switch name {
+		case ChannelSNS:
+			channel = NewSNSNotificationRepository(NewSNSClient(cfg.Notification), cfg, logger)
+		case ChannelSMTP:
+			channel = NewSMTPNotificationRepository(cfg.Notification, logger)
+		case ChannelWebhook:
+			channel = NewWebhookNotificationRepository(cfg.Notification, logger)
+		case ChannelSlack:
+			channel = NewSlackNotificationRepository(cfg.Notification, logger)
+		case ChannelLog:
+			channel = NewLogNotificationRepository(logger)
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+		channels = append(channels, channel)
+		names = append(names, name)
 	}
-}
 
-func (s *NotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
-	s.log.InfoWithID(ctx, "[Repository: SendAdminAlert] Called")
-	return s.publishAlert(ctx, s.cfg.Notification.AdminTopicArn, "[Repository: SendAdminAlert]", alert)
+	switch len(channels) {
+	case 0:
+		return nil, errors.New("NOTIFICATION_CHANNELS must name at least one channel")
+	case 1:
+		return channels[0], nil
+	default:
+		return NewMultiNotificationRepository(names, channels, logger), nil
+	}
 }
 
-func (s *NotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
-	s.log.InfoWithID(ctx, "[Repository: SendUserAlert] Called")
-	return s.publishAlert(ctx, s.cfg.Notification.UserTopicArn, "[Repository: SendUserAlert]", alert)
+// MultiNotificationRepository fans every call out to several channels. A
+// failing channel does not stop delivery to the others.
+type MultiNotificationRepository struct {
+	names    []string
+	channels []INotificationRepository
+	log      log.LoggerInterface
 }
 
-func (s *NotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
-	s.log.InfoWithID(ctx, "[Repository: SubscribeToUserTopic] Called")
-	return s.subscribeEmail(ctx, s.cfg.Notification.UserTopicArn, "[Repository: SubscribeToUserTopic]", email)
+func NewMultiNotificationRepository(names []string, channels []INotificationRepository, logger log.LoggerInterface) INotificationRepository {
+	return &MultiNotificationRepository{
+		names:    names,
+		channels: channels,
+		log:      logger,
+	}
 }
 
-func (s *NotificationRepository) publishAlert(ctx context.Context, topicArn, logPrefix string, alert entity.Alert) error {
-	s.log.InfoWithID(ctx, logPrefix+" Called")
-
-	_, err := s.client.Publish(ctx, &sns.PublishInput{
-		TopicArn: aws.String(topicArn),
-		Subject:  aws.String(alert.Subject),
-		Message:  aws.String(alert.Message),
+func (m *MultiNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	return m.each(ctx, "[Repository: SendAdminAlert]", func(channel INotificationRepository) error {
+		return channel.SendAdminAlert(ctx, alert)
 	})
-
-	if err != nil {
-		s.log.ErrorWithID(ctx, logPrefix+" Failed to publish message:", err)
-		return fmt.Errorf("failed to publish message to topic %s: %w", topicArn, err)
-	}
-
-	s.log.InfoWithID(ctx, logPrefix+" Alert successfully published")
-	return nil
 }
 
-func (s *NotificationRepository) subscribeEmail(ctx context.Context, topicArn, logPrefix, email string) error {
-	s.log.InfoWithID(ctx, logPrefix+" Called")
-
-	_, err := s.client.Subscribe(ctx, &sns.SubscribeInput{
-		TopicArn: aws.String(topicArn),
-		Protocol: aws.String("email"),
-		Endpoint: aws.String(email),
+func (m *MultiNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	return m.each(ctx, "[Repository: SendUserAlert]", func(channel INotificationRepository) error {
+		return channel.SendUserAlert(ctx, alert)
 	})
-
-	if err != nil {
-		s.log.ErrorWithID(ctx, logPrefix+" Failed to subscribe:", err)
-		return fmt.Errorf("failed to subscribe %s to topic %s: %w", email, topicArn, err)
-	}
-
-	s.log.InfoWithID(ctx, logPrefix+" Subscription requested for "+email)
-	return nil
 }
 
-func (s *NotificationRepository) GetAdminSubscriptions(ctx context.Context) ([]string, error) {
-	s.log.InfoWithID(ctx, "[Repository: GetAdminSubscriptions] Called")
-
-	var subscriptions []string
-	var nextToken *string
-
-	for {
-		resp, err := s.client.ListSubscriptionsByTopic(ctx, &sns.ListSubscriptionsByTopicInput{
-			TopicArn:  aws.String(s.cfg.Notification.AdminTopicArn),
-			NextToken: nextToken,
-		})
-		if err != nil {
-			s.log.ErrorWithID(ctx, "[Repository: GetAdminSubscriptions] Failed to list subscriptions:", err)
-			return nil, fmt.Errorf("failed to list subscriptions for topic %s: %w", s.cfg.Notification.AdminTopicArn, err)
-		}
-
-		for _, sub := range resp.Subscriptions {
-			if sub.Endpoint != nil {
-				subscriptions = append(subscriptions, *sub.Endpoint)
-			}
-		}
+func (m *MultiNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	return m.each(ctx, "[Repository: SubscribeToUserTopic]", func(channel INotificationRepository) error {
+		return channel.SubscribeToUserTopic(ctx, email)
+	})
+}
 
-		if resp.NextToken == nil {
-			break
+func (m *MultiNotificationRepository) each(ctx context.Context, logPrefix string, call func(INotificationRepository) error) error {
+	var errs []error
+	for i, channel := range m.channels {
+		if err := call(channel); err != nil {
+			m.log.ErrorWithID(ctx, logPrefix+" Channel "+m.names[i]+" failed:", err)
+			errs = append(errs, fmt.Errorf("%s: %w", m.names[i], err))
 		}
-		nextToken = resp.NextToken
 	}
-
-	s.log.InfoWithID(ctx, "[Repository: GetAdminSubscriptions] Found subscriptions:", len(subscriptions))
-	return subscriptions, nil
+	return errors.Join(errs...)
 }
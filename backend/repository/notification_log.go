@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+)
+
+// LogNotificationRepository only writes notifications to the application log.
+// It is meant for local development where no real channel is configured.
+type LogNotificationRepository struct {
+	log log.LoggerInterface
+}
+
+func NewLogNotificationRepository(logger log.LoggerInterface) INotificationRepository {
+	return &LogNotificationRepository{
+		log: logger,
+	}
+}
+
+func (l *LogNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	l.log.InfoWithID(ctx, "[Repository: Log SendAdminAlert]", alert.Subject, "-", alert.Message)
+	return nil
+}
+
+func (l *LogNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	l.log.InfoWithID(ctx, "[Repository: Log SendUserAlert]", alert.Subject, "-", alert.Message)
+	return nil
+}
+
+func (l *LogNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	l.log.InfoWithID(ctx, "[Repository: Log SubscribeToUserTopic]", email)
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	cfg "github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+)
+
+// SMTPNotificationRepository emails admin alerts to SMTP_ADMIN_TO and user
+// alerts to SMTP_USER_TO (typically a mailing list address).
+type SMTPNotificationRepository struct {
+	cfg cfg.NotificationConfig
+	log log.LoggerInterface
+}
+
+func NewSMTPNotificationRepository(cfg cfg.NotificationConfig, logger log.LoggerInterface) INotificationRepository {
+	return &SMTPNotificationRepository{
+		cfg: cfg,
+		log: logger,
+	}
+}
+
+func (s *SMTPNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: SMTP SendAdminAlert] Called")
+	return s.send(ctx, "[Repository: SMTP SendAdminAlert]", s.cfg.SMTPAdminTo, alert)
+}
+
+func (s *SMTPNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: SMTP SendUserAlert] Called")
+	return s.send(ctx, "[Repository: SMTP SendUserAlert]", s.cfg.SMTPUserTo, alert)
+}
+
+// SubscribeToUserTopic is a no-op: SMTP user alerts go to a fixed list
+// address whose membership is managed outside this service.
+func (s *SMTPNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	s.log.InfoWithID(ctx, "[Repository: SMTP SubscribeToUserTopic] Nothing to do for "+email)
+	return nil
+}
+
+func (s *SMTPNotificationRepository) send(ctx context.Context, logPrefix, recipients string, alert entity.Alert) error {
+	to := splitList(recipients)
+	if len(to) == 0 {
+		s.log.InfoWithID(ctx, logPrefix+" No recipients configured, skipping")
+		return nil
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.SMTPFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", alert.Subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.Message, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(s.cfg.SMTPHost, s.cfg.SMTPPort)
+	if err := s.sendMail(ctx, addr, auth, to, []byte(msg.String())); err != nil {
+		s.log.ErrorWithID(ctx, logPrefix+" Failed to send email:", err)
+		return fmt.Errorf("failed to send email via %s: %w", addr, err)
+	}
+
+	s.log.InfoWithID(ctx, logPrefix+" Email sent to "+strings.Join(to, ", "))
+	return nil
+}
+
+// sendMail does what smtp.SendMail does, upgrading to STARTTLS whenever the
+// server offers it, but gives up after constant.HTTPTimeout or when ctx ends
+// so a hung server cannot stall the outbox dispatcher.
+func (s *SMTPNotificationRepository) sendMail(ctx context.Context, addr string, auth smtp.Auth, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, constant.HTTPTimeout)
+	defer cancel()
+
+	dialer := net.Dialer{Timeout: constant.HTTPTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Unblock a read or write in progress if ctx is cancelled early.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.SMTPHost)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.cfg.SMTPFrom); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// splitList parses a comma-separated config value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	cfg "github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+)
+
+// SNSNotificationRepository publishes alerts to the admin and user SNS topics.
+type SNSNotificationRepository struct {
+	client *sns.Client
+	cfg    cfg.Config
+	log    log.LoggerInterface
+}
+
+// NewSNSClient builds an SNS client for the configured region and credentials.
+func NewSNSClient(cfg cfg.NotificationConfig) *sns.Client {
+	customCreds := aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
+		cfg.AccessKey,
+		cfg.SecretKey,
+		cfg.SessionToken,
+	))
+
+	// Set custom HTTP client to skip SSL certificate verification
+	customHTTPClient := &http.Client{
+		Timeout: constant.HTTPTimeout,
+		Transport: &http.Transport{
+			// Use default TLS settings (including CA certificates)
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: false, // Set to false to enable certificate verification
+			},
+		},
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = constant.SNSRegion
+	}
+
+	// Return a new SNS client with the custom HTTP client
+	return sns.New(sns.Options{
+		Credentials: customCreds,
+		Region:      region,
+		HTTPClient:  customHTTPClient, // Use the custom HTTP client
+	})
+}
+
+func NewSNSNotificationRepository(client *sns.Client, cfg cfg.Config, logger log.LoggerInterface) INotificationRepository {
+	return &SNSNotificationRepository{
+		client: client,
+		cfg:    cfg,
+		log:    logger,
+	}
+}
+
+func (s *SNSNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: SendAdminAlert] Called")
+	return s.publishAlert(ctx, s.cfg.Notification.AdminTopicArn, "[Repository: SendAdminAlert]", alert)
+}
+
+func (s *SNSNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: SendUserAlert] Called")
+	return s.publishAlert(ctx, s.cfg.Notification.UserTopicArn, "[Repository: SendUserAlert]", alert)
+}
+
+func (s *SNSNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	s.log.InfoWithID(ctx, "[Repository: SubscribeToUserTopic] Called")
+	return s.subscribeEmail(ctx, s.cfg.Notification.UserTopicArn, "[Repository: SubscribeToUserTopic]", email)
+}
+
+func (s *SNSNotificationRepository) publishAlert(ctx context.Context, topicArn, logPrefix string, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, logPrefix+" Called")
+
+	_, err := s.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(topicArn),
+		Subject:  aws.String(alert.Subject),
+		Message:  aws.String(alert.Message),
+	})
+
+	if err != nil {
+		s.log.ErrorWithID(ctx, logPrefix+" Failed to publish message:", err)
+		return fmt.Errorf("failed to publish message to topic %s: %w", topicArn, err)
+	}
+
+	s.log.InfoWithID(ctx, logPrefix+" Alert successfully published")
+	return nil
+}
+
+func (s *SNSNotificationRepository) subscribeEmail(ctx context.Context, topicArn, logPrefix, email string) error {
+	s.log.InfoWithID(ctx, logPrefix+" Called")
+
+	_, err := s.client.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn: aws.String(topicArn),
+		Protocol: aws.String("email"),
+		Endpoint: aws.String(email),
+	})
+
+	if err != nil {
+		s.log.ErrorWithID(ctx, logPrefix+" Failed to subscribe:", err)
+		return fmt.Errorf("failed to subscribe %s to topic %s: %w", email, topicArn, err)
+	}
+
+	s.log.InfoWithID(ctx, logPrefix+" Subscription requested for "+email)
+	return nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	cfg "github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+)
+
+// webhookEvent is the JSON body POSTed to WEBHOOK_URL.
+type webhookEvent struct {
+	Event     string    `json:"event"`
+	Audience  string    `json:"audience,omitempty"`
+	Subject   string    `json:"subject,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookNotificationRepository POSTs every notification as JSON to a generic
+// HTTP endpoint. When WEBHOOK_SECRET is set the body is signed with
+// HMAC-SHA256 in the X-Signature-256 header ("sha256=<hex>").
+type WebhookNotificationRepository struct {
+	client *http.Client
+	url    string
+	secret string
+	log    log.LoggerInterface
+}
+
+func NewWebhookNotificationRepository(cfg cfg.NotificationConfig, logger log.LoggerInterface) INotificationRepository {
+	return &WebhookNotificationRepository{
+		client: &http.Client{Timeout: constant.HTTPTimeout},
+		url:    cfg.WebhookURL,
+		secret: cfg.WebhookSecret,
+		log:    logger,
+	}
+}
+
+func (w *WebhookNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	w.log.InfoWithID(ctx, "[Repository: Webhook SendAdminAlert] Called")
+	return w.post(ctx, "[Repository: Webhook SendAdminAlert]", webhookEvent{
+		Event:    "alert",
+		Audience: "admin",
+		Subject:  alert.Subject,
+		Message:  alert.Message,
+	})
+}
+
+func (w *WebhookNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	w.log.InfoWithID(ctx, "[Repository: Webhook SendUserAlert] Called")
+	return w.post(ctx, "[Repository: Webhook SendUserAlert]", webhookEvent{
+		Event:    "alert",
+		Audience: "user",
+		Subject:  alert.Subject,
+		Message:  alert.Message,
+	})
+}
+
+func (w *WebhookNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	w.log.InfoWithID(ctx, "[Repository: Webhook SubscribeToUserTopic] Called")
+	return w.post(ctx, "[Repository: Webhook SubscribeToUserTopic]", webhookEvent{
+		Event: "subscribe",
+		Email: email,
+	})
+}
+
+func (w *WebhookNotificationRepository) post(ctx context.Context, logPrefix string, event webhookEvent) error {
+	event.Timestamp = time.Now().UTC()
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		headers["X-Signature-256"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	if err := postJSON(ctx, w.client, w.url, body, headers); err != nil {
+		w.log.ErrorWithID(ctx, logPrefix+" Failed to deliver webhook:", err)
+		return err
+	}
+	w.log.InfoWithID(ctx, logPrefix+" Webhook delivered")
+	return nil
+}
+
+// SlackNotificationRepository posts alerts to a Slack-compatible incoming
+// webhook (Slack, Mattermost, Rocket.Chat, ...).
+type SlackNotificationRepository struct {
+	client *http.Client
+	url    string
+	log    log.LoggerInterface
+}
+
+func NewSlackNotificationRepository(cfg cfg.NotificationConfig, logger log.LoggerInterface) INotificationRepository {
+	return &SlackNotificationRepository{
+		client: &http.Client{Timeout: constant.HTTPTimeout},
+		url:    cfg.SlackWebhookURL,
+		log:    logger,
+	}
+}
+
+func (s *SlackNotificationRepository) SendAdminAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: Slack SendAdminAlert] Called")
+	return s.post(ctx, "[Repository: Slack SendAdminAlert]", ":rotating_light: *"+alert.Subject+"*\n"+alert.Message)
+}
+
+func (s *SlackNotificationRepository) SendUserAlert(ctx context.Context, alert entity.Alert) error {
+	s.log.InfoWithID(ctx, "[Repository: Slack SendUserAlert] Called")
+	return s.post(ctx, "[Repository: Slack SendUserAlert]", "*"+alert.Subject+"*\n"+alert.Message)
+}
+
+// SubscribeToUserTopic is a no-op: channel membership is managed in Slack.
+func (s *SlackNotificationRepository) SubscribeToUserTopic(ctx context.Context, email string) error {
+	s.log.InfoWithID(ctx, "[Repository: Slack SubscribeToUserTopic] Nothing to do for "+email)
+	return nil
+}
+
+func (s *SlackNotificationRepository) post(ctx context.Context, logPrefix, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	if err := postJSON(ctx, s.client, s.url, body, nil); err != nil {
+		s.log.ErrorWithID(ctx, logPrefix+" Failed to post message:", err)
+		return err
+	}
+	s.log.InfoWithID(ctx, logPrefix+" Message posted")
+	return nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	if url == "" {
+		return fmt.Errorf("webhook URL is not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
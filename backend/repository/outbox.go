@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"gorm.io/gorm"
+)
+
+// IOutboxRepository stores notifications until the dispatcher delivers them.
+type IOutboxRepository interface {
+	Enqueue(ctx context.Context, msgs ...model.OutboxMessage) error
+	// ClaimDue leases up to limit due messages to the caller: their next
+	// attempt is pushed back by lease so no other dispatcher picks them up
+	// while they are being delivered.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkSent(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error
+	FindByStatus(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error)
+	// Requeue moves a dead message back to pending with a fresh attempt count.
+	Requeue(ctx context.Context, id uuid.UUID) error
+}
+
+type outboxRepository struct {
+	db  *gorm.DB
+	log log.LoggerInterface
+}
+
+// NewOutboxRepository creates a new outboxRepository with injected DB and logger.
+func NewOutboxRepository(db *gorm.DB, logger log.LoggerInterface) IOutboxRepository {
+	return &outboxRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ob *outboxRepository) Enqueue(ctx context.Context, msgs ...model.OutboxMessage) error {
+	ob.log.InfoWithID(ctx, "[Repository: EnqueueOutbox] Called for messages:", len(msgs))
+	if err := insertOutbox(ob.db.WithContext(ctx), msgs); err != nil {
+		ob.log.ErrorWithID(ctx, "[Repository: EnqueueOutbox] Error inserting messages:", err)
+		return err
+	}
+	return nil
+}
+
+// insertOutbox writes msgs with tx so callers can enqueue notifications in
+// the same transaction as the change that triggers them.
+func insertOutbox(tx *gorm.DB, msgs []model.OutboxMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range msgs {
+		if msgs[i].Status == "" {
+			msgs[i].Status = model.OutboxStatusPending
+		}
+		if msgs[i].NextAttemptAt.IsZero() {
+			msgs[i].NextAttemptAt = now
+		}
+	}
+	return tx.Create(&msgs).Error
+}
+
+func (ob *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var msgs []model.OutboxMessage
+	err := ob.db.WithContext(ctx).Raw(`
+UPDATE notification_outbox
+SET attempts = attempts + 1, next_attempt_at = ?
+WHERE id IN (
+  SELECT id FROM notification_outbox
+  WHERE status = ? AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT ?
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *`, time.Now().Add(lease), model.OutboxStatusPending, limit).Scan(&msgs).Error
+	if err != nil {
+		ob.log.ErrorWithID(ctx, "[Repository: ClaimDue] Error claiming messages:", err)
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (ob *outboxRepository) MarkSent(ctx context.Context, id uuid.UUID) error {
+	return ob.db.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     model.OutboxStatusSent,
+		"sent_at":    time.Now(),
+		"last_error": "",
+	}).Error
+}
+
+func (ob *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := model.OutboxStatusPending
+	if dead {
+		status = model.OutboxStatusDead
+	}
+	return ob.db.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
+
+func (ob *outboxRepository) FindByStatus(ctx context.Context, status string, limit int) ([]model.OutboxMessage, error) {
+	ob.log.InfoWithID(ctx, "[Repository: FindOutboxByStatus] Called for status:", status)
+	var msgs []model.OutboxMessage
+	if err := ob.db.WithContext(ctx).Where("status = ?", status).Order("created_at DESC").Limit(limit).Find(&msgs).Error; err != nil {
+		ob.log.ErrorWithID(ctx, "[Repository: FindOutboxByStatus] Error retrieving messages:", err)
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (ob *outboxRepository) Requeue(ctx context.Context, id uuid.UUID) error {
+	ob.log.InfoWithID(ctx, "[Repository: RequeueOutbox] Called for id:", id)
+	result := ob.db.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, model.OutboxStatusDead).
+		Updates(map[string]interface{}{
+			"status":          model.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		ob.log.ErrorWithID(ctx, "[Repository: RequeueOutbox] Error requeueing message:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -2,21 +2,82 @@ package repository
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/guncv/Poll-Voting-Website/backend/log"
 	"github.com/guncv/Poll-Voting-Website/backend/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// archiveLockID keys the Postgres advisory lock that keeps the archivers of
+// several instances from rewriting the same polls at once.
+const archiveLockID = 7_311_003
+
+// ErrArchiveLocked is returned by LockArchive while another instance holds
+// the archive lock.
+var ErrArchiveLocked = errors.New("another instance is archiving")
+
+// Archive sort orders accepted by QuestionFilter.Sort.
+const (
+	QuestionSortDate         = "date"
+	QuestionSortParticipants = "participants"
+	QuestionSortMargin       = "margin"
+)
+
+var questionSortColumns = map[string]string{
+	QuestionSortDate:         "archive_date",
+	QuestionSortParticipants: "total_participants",
+	QuestionSortMargin:       "margin_of_victory",
+}
+
+// ValidQuestionSort reports whether sort is one of the QuestionSort orders.
+func ValidQuestionSort(sort string) bool {
+	_, ok := questionSortColumns[sort]
+	return ok
+}
+
+// QuestionCursor is the sort key of the last question of a page. Value holds
+// the archive date formatted YYYY-MM-DD when sorting by date and the count
+// otherwise.
+type QuestionCursor struct {
+	Value interface{}
+	ID    uuid.UUID
+}
+
+// QuestionFilter narrows and orders the archive. From and To are inclusive
+// archive days formatted YYYY-MM-DD; empty means unbounded. Questions tied on
+// the sort column are ordered by ID so every page boundary is stable.
+type QuestionFilter struct {
+	From            string
+	To              string
+	CreatedBy       *uuid.UUID
+	MinParticipants int
+	Sort            string
+	Ascending       bool
+	After           *QuestionCursor
+	Limit           int
+}
+
 // QuestionRepository defines database operations for questions.
 type QuestionRepository interface {
-	CreateQuestion(ctx context.Context, q model.Question) (model.Question, error)
-	FindByID(ctx context.Context, id int) (model.Question, error)
-	FindAll(ctx context.Context) ([]model.Question, error)
-	DeleteQuestion(ctx context.Context, id int) error
+	// CreateQuestion inserts q together with any outbox messages in one transaction.
+	CreateQuestion(ctx context.Context, q model.Question, outbox ...model.OutboxMessage) (model.Question, error)
+	FindByID(ctx context.Context, id uuid.UUID) (model.Question, error)
+	// FindPage lists up to filter.Limit questions following filter.After.
+	FindPage(ctx context.Context, filter QuestionFilter) ([]model.Question, error)
+	// Search returns up to limit questions matching a web-style query
+	// ("quoted phrases", or, -exclusions), best match first.
+	Search(ctx context.Context, query string, limit int) ([]model.Question, error)
+	DeleteQuestion(ctx context.Context, id uuid.UUID) error
 	FindLastArchivedQuestion(ctx context.Context) (model.Question, error)
+	UpsertQuestion(ctx context.Context, q model.Question) (model.Question, error)
+	// LockArchive takes the archive lock without waiting, or returns
+	// ErrArchiveLocked. unlock releases it.
+	LockArchive(ctx context.Context) (unlock func(), err error)
 }
 
 type questionRepository struct {
@@ -32,9 +93,15 @@ func NewQuestionRepository(db *gorm.DB, logger log.LoggerInterface) QuestionRepo
 	}
 }
 
-func (qr *questionRepository) CreateQuestion(ctx context.Context, q model.Question) (model.Question, error) {
+func (qr *questionRepository) CreateQuestion(ctx context.Context, q model.Question, outbox ...model.OutboxMessage) (model.Question, error) {
 	qr.log.InfoWithID(ctx, "[Repository: CreateQuestion] Called for question:", q.QuestionText)
-	if err := qr.db.Create(&q).Error; err != nil {
+	err := qr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&q).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+	if err != nil {
 		qr.log.ErrorWithID(ctx, "[Repository: CreateQuestion] Error creating question:", err)
 		return model.Question{}, err
 	}
@@ -42,10 +109,10 @@ func (qr *questionRepository) CreateQuestion(ctx context.Context, q model.Questi
 	return q, nil
 }
 
-func (qr *questionRepository) FindByID(ctx context.Context, id int) (model.Question, error) {
+func (qr *questionRepository) FindByID(ctx context.Context, id uuid.UUID) (model.Question, error) {
 	qr.log.InfoWithID(ctx, "[Repository: FindByID] Called for question id:", id)
 	var question model.Question
-	if err := qr.db.First(&question, id).Error; err != nil {
+	if err := qr.db.WithContext(ctx).Preload("Options", orderByPosition).Where("question_id = ?", id).First(&question).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			qr.log.ErrorWithID(ctx, "[Repository: FindByID] Question not found with id:", id)
 			return model.Question{}, gorm.ErrRecordNotFound
@@ -57,22 +124,79 @@ func (qr *questionRepository) FindByID(ctx context.Context, id int) (model.Quest
 	return question, nil
 }
 
-func (qr *questionRepository) FindAll(ctx context.Context) ([]model.Question, error) {
-	qr.log.InfoWithID(ctx, "[Repository: FindAll] Called")
+func (qr *questionRepository) FindPage(ctx context.Context, filter QuestionFilter) ([]model.Question, error) {
+	qr.log.InfoWithID(ctx, "[Repository: FindPage] Called with sort:", filter.Sort)
+	column, ok := questionSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown question sort %q", filter.Sort)
+	}
+	direction, after := "DESC", "<"
+	if filter.Ascending {
+		direction, after = "ASC", ">"
+	}
+
+	query := qr.db.WithContext(ctx).Preload("Options", orderByPosition)
+	if filter.From != "" {
+		query = query.Where("archive_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("archive_date <= ?", filter.To)
+	}
+	if filter.CreatedBy != nil {
+		query = query.Where("created_by = ?", *filter.CreatedBy)
+	}
+	if filter.MinParticipants > 0 {
+		query = query.Where("total_participants >= ?", filter.MinParticipants)
+	}
+	if filter.After != nil {
+		query = query.Where(fmt.Sprintf("(%s, question_id) %s (?, ?)", column, after), filter.After.Value, filter.After.ID)
+	}
+
 	var questions []model.Question
-	if err := qr.db.Find(&questions).Error; err != nil {
-		qr.log.ErrorWithID(ctx, "[Repository: FindAll] Error retrieving questions:", err)
+	err := query.
+		Order(column + " " + direction).
+		Order("question_id " + direction).
+		Limit(filter.Limit).
+		Find(&questions).Error
+	if err != nil {
+		qr.log.ErrorWithID(ctx, "[Repository: FindPage] Error retrieving questions:", err)
 		return nil, err
 	}
-	qr.log.InfoWithID(ctx, "[Repository: FindAll] Successfully retrieved questions")
+	qr.log.InfoWithID(ctx, "[Repository: FindPage] Retrieved questions:", len(questions))
 	return questions, nil
 }
 
-func (qr *questionRepository) DeleteQuestion(ctx context.Context, id int) error {
+func (qr *questionRepository) Search(ctx context.Context, query string, limit int) ([]model.Question, error) {
+	qr.log.InfoWithID(ctx, "[Repository: Search] Called with query:", query)
+	var questions []model.Question
+	err := qr.db.WithContext(ctx).
+		Preload("Options", orderByPosition).
+		Where("search_vector @@ websearch_to_tsquery('simple', ?)", query).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(search_vector, websearch_to_tsquery('simple', ?)) DESC, archive_date DESC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&questions).Error
+	if err != nil {
+		qr.log.ErrorWithID(ctx, "[Repository: Search] Error searching questions:", err)
+		return nil, err
+	}
+	qr.log.InfoWithID(ctx, "[Repository: Search] Found questions:", len(questions))
+	return questions, nil
+}
+
+func (qr *questionRepository) DeleteQuestion(ctx context.Context, id uuid.UUID) error {
 	qr.log.InfoWithID(ctx, "[Repository: DeleteQuestion] Called for question id:", id)
-	if err := qr.db.Delete(&model.Question{}, id).Error; err != nil {
-		qr.log.ErrorWithID(ctx, "[Repository: DeleteQuestion] Error deleting question:", err)
-		return err
+	result := qr.db.WithContext(ctx).Where("question_id = ?", id).Delete(&model.Question{})
+	if result.Error != nil {
+		qr.log.ErrorWithID(ctx, "[Repository: DeleteQuestion] Error deleting question:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		qr.log.ErrorWithID(ctx, "[Repository: DeleteQuestion] Question not found with id:", id)
+		return gorm.ErrRecordNotFound
 	}
 	qr.log.InfoWithID(ctx, "[Repository: DeleteQuestion] Successfully deleted question with id:", id)
 	return nil
@@ -82,6 +206,7 @@ func (qr *questionRepository) FindLastArchivedQuestion(ctx context.Context) (mod
 	qr.log.InfoWithID(ctx, "[Repository: FindLastArchivedQuestion] Called")
     var q model.Question
     if err := qr.db.WithContext(ctx).
+        Preload("Options", orderByPosition).
         Order("archive_date DESC").
         First(&q).Error; err != nil {
 		qr.log.ErrorWithID(ctx, "[Repository: FindLastArchivedQuestion] Error finding last archived question:", err)
@@ -93,4 +218,89 @@ func (qr *questionRepository) FindLastArchivedQuestion(ctx context.Context) (mod
 	}
 	qr.log.InfoWithID(ctx, "[Repository: FindLastArchivedQuestion] Successfully found last archived question with id:", q.QuestionID)
     return q, nil
-}
\ No newline at end of file
+}
+
+// UpsertQuestion inserts the question and its options or, when rows with the
+// same IDs already exist, overwrites their tallies. This keeps archiving idempotent.
+func (qr *questionRepository) UpsertQuestion(ctx context.Context, q model.Question) (model.Question, error) {
+	qr.log.InfoWithID(ctx, "[Repository: UpsertQuestion] Called for question id:", q.QuestionID)
+	err := qr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "question_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"archive_date",
+				"question_text",
+				"first_choice",
+				"second_choice",
+				"total_participants",
+				"first_choice_count",
+				"second_choice_count",
+				"margin_of_victory",
+				"opens_at",
+				"closes_at",
+			}),
+		}).Create(&q).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range q.Options {
+			q.Options[i].QuestionID = q.QuestionID
+		}
+		if len(q.Options) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "option_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"position", "option_text", "vote_count"}),
+		}).Create(&q.Options).Error
+	})
+	if err != nil {
+		qr.log.ErrorWithID(ctx, "[Repository: UpsertQuestion] Error upserting question:", err)
+		return model.Question{}, err
+	}
+	qr.log.InfoWithID(ctx, "[Repository: UpsertQuestion] Successfully upserted question with id:", q.QuestionID)
+	return q, nil
+}
+
+// orderByPosition preloads options in the order they were presented.
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// LockArchive holds a session-level advisory lock on a connection of its own,
+// since one archive run spans many transactions.
+func (qr *questionRepository) LockArchive(ctx context.Context) (func(), error) {
+	qr.log.InfoWithID(ctx, "[Repository: LockArchive] Called")
+	sqlDB, err := qr.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		qr.log.ErrorWithID(ctx, "[Repository: LockArchive] Error getting connection:", err)
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", archiveLockID).Scan(&locked); err != nil {
+		conn.Close()
+		qr.log.ErrorWithID(ctx, "[Repository: LockArchive] Error taking lock:", err)
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrArchiveLocked
+	}
+
+	return func() {
+		// ctx may be cancelled by now; the lock must be released regardless.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", archiveLockID); err != nil {
+			qr.log.ErrorWithID(ctx, "[Repository: LockArchive] Error releasing lock:", err)
+			// Drop the connection instead of pooling it, which ends the
+			// session and with it the lock.
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"gorm.io/gorm"
+)
+
+// ScheduledQuestionRepository stores polls that open at a later time.
+type ScheduledQuestionRepository interface {
+	CreateScheduledQuestion(ctx context.Context, sq model.ScheduledQuestion) (model.ScheduledQuestion, error)
+	// FindUpcomingByUser lists the pending polls of userID, soonest first.
+	FindUpcomingByUser(ctx context.Context, userID uuid.UUID) ([]model.ScheduledQuestion, error)
+	// ClaimDue leases up to limit pending polls whose opens_at has passed to
+	// the caller, who opens them after the claim is committed. No other
+	// scheduler claims them again until lease runs out. Polls whose closes_at
+	// passed before they could be activated are marked expired first.
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.ScheduledQuestion, error)
+	// MarkActive records that a claimed poll is live.
+	MarkActive(ctx context.Context, id uuid.UUID, activatedAt time.Time) error
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.ScheduledQuestion, error)
+	// ReleaseHeld makes a held follow-up pending with the given window and
+	// reports whether it was still held.
+	ReleaseHeld(ctx context.Context, id uuid.UUID, opensAt, closesAt time.Time) (bool, error)
+	// HoldUntil keeps the held follow-ups among ids from expiring before
+	// until, when the poll that can release them closes.
+	HoldUntil(ctx context.Context, ids []uuid.UUID, until time.Time) error
+}
+
+type scheduledQuestionRepository struct {
+	db  *gorm.DB
+	log log.LoggerInterface
+}
+
+// NewScheduledQuestionRepository creates a new scheduledQuestionRepository with injected DB and logger.
+func NewScheduledQuestionRepository(db *gorm.DB, logger log.LoggerInterface) ScheduledQuestionRepository {
+	return &scheduledQuestionRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (sr *scheduledQuestionRepository) CreateScheduledQuestion(ctx context.Context, sq model.ScheduledQuestion) (model.ScheduledQuestion, error) {
+	sr.log.InfoWithID(ctx, "[Repository: CreateScheduledQuestion] Called for question:", sq.QuestionID)
+	if sq.Status == "" {
+		sq.Status = model.ScheduleStatusPending
+	}
+	if err := sr.db.WithContext(ctx).Create(&sq).Error; err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: CreateScheduledQuestion] Error creating scheduled question:", err)
+		return model.ScheduledQuestion{}, err
+	}
+	sr.log.InfoWithID(ctx, "[Repository: CreateScheduledQuestion] Successfully scheduled question for:", sq.OpensAt)
+	return sq, nil
+}
+
+func (sr *scheduledQuestionRepository) FindUpcomingByUser(ctx context.Context, userID uuid.UUID) ([]model.ScheduledQuestion, error) {
+	sr.log.InfoWithID(ctx, "[Repository: FindUpcomingByUser] Called for user:", userID)
+	var questions []model.ScheduledQuestion
+	err := sr.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, model.ScheduleStatusPending).
+		Order("opens_at ASC").
+		Find(&questions).Error
+	if err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: FindUpcomingByUser] Error retrieving scheduled questions:", err)
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (sr *scheduledQuestionRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.ScheduledQuestion, error) {
+	expired := sr.db.WithContext(ctx).Model(&model.ScheduledQuestion{}).
+		Where("status IN ? AND closes_at <= ?", []string{model.ScheduleStatusHeld, model.ScheduleStatusPending}, now).
+		Update("status", model.ScheduleStatusExpired)
+	if expired.Error != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: ClaimDue] Error expiring scheduled questions:", expired.Error)
+		return nil, expired.Error
+	}
+	if expired.RowsAffected > 0 {
+		sr.log.ErrorWithID(ctx, "[Repository: ClaimDue] Expired polls that closed before activation or release:", expired.RowsAffected)
+	}
+
+	// SKIP LOCKED lets several instances run the scheduler side by side; the
+	// row locks only last for this statement.
+	var due []model.ScheduledQuestion
+	err := sr.db.WithContext(ctx).Raw(`
+UPDATE scheduled_questions
+SET claimed_until = ?
+WHERE question_id IN (
+  SELECT question_id FROM scheduled_questions
+  WHERE status = ? AND opens_at <= ? AND (claimed_until IS NULL OR claimed_until <= ?)
+  ORDER BY opens_at
+  LIMIT ?
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *`, now.Add(lease), model.ScheduleStatusPending, now, now, limit).Scan(&due).Error
+	if err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: ClaimDue] Error claiming scheduled questions:", err)
+		return nil, err
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].OpensAt.Before(due[j].OpensAt) })
+	return due, nil
+}
+
+func (sr *scheduledQuestionRepository) MarkActive(ctx context.Context, id uuid.UUID, activatedAt time.Time) error {
+	err := sr.db.WithContext(ctx).Model(&model.ScheduledQuestion{}).
+		Where("question_id = ? AND status = ?", id, model.ScheduleStatusPending).
+		Updates(map[string]interface{}{
+			"status":        model.ScheduleStatusActive,
+			"activated_at":  activatedAt,
+			"claimed_until": nil,
+		}).Error
+	if err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: MarkActive] Error activating question:", id, err)
+		return err
+	}
+	return nil
+}
+
+func (sr *scheduledQuestionRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.ScheduledQuestion, error) {
+	sr.log.InfoWithID(ctx, "[Repository: FindScheduledByIDs] Called for questions:", len(ids))
+	var questions []model.ScheduledQuestion
+	if len(ids) == 0 {
+		return questions, nil
+	}
+	if err := sr.db.WithContext(ctx).Where("question_id IN ?", ids).Find(&questions).Error; err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: FindScheduledByIDs] Error retrieving scheduled questions:", err)
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (sr *scheduledQuestionRepository) ReleaseHeld(ctx context.Context, id uuid.UUID, opensAt, closesAt time.Time) (bool, error) {
+	sr.log.InfoWithID(ctx, "[Repository: ReleaseHeld] Called for question:", id)
+	result := sr.db.WithContext(ctx).Model(&model.ScheduledQuestion{}).
+		Where("question_id = ? AND status = ?", id, model.ScheduleStatusHeld).
+		Updates(map[string]interface{}{
+			"status":    model.ScheduleStatusPending,
+			"opens_at":  opensAt,
+			"closes_at": closesAt,
+		})
+	if result.Error != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: ReleaseHeld] Error releasing question:", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (sr *scheduledQuestionRepository) HoldUntil(ctx context.Context, ids []uuid.UUID, until time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := sr.db.WithContext(ctx).Model(&model.ScheduledQuestion{}).
+		Where("question_id IN ? AND status = ? AND closes_at < ?", ids, model.ScheduleStatusHeld, until).
+		Update("closes_at", until).Error
+	if err != nil {
+		sr.log.ErrorWithID(ctx, "[Repository: HoldUntil] Error extending held questions:", err)
+		return err
+	}
+	return nil
+}
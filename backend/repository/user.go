@@ -11,7 +11,8 @@ import (
 
 // UserRepository defines user database operations.
 type UserRepository interface {
-	CreateUser(ctx context.Context, u model.User) (model.User, error)
+	// CreateUser inserts u together with any outbox messages in one transaction.
+	CreateUser(ctx context.Context, u model.User, outbox ...model.OutboxMessage) (model.User, error)
 	FindByEmail(ctx context.Context, email string) (model.User, error)
 	FindByID(ctx context.Context, id string) (model.User, error)
 	UpdateUser(ctx context.Context, u model.User) (model.User, error)
@@ -31,9 +32,15 @@ func NewUserRepository(db *gorm.DB, logger log.LoggerInterface) UserRepository {
 	}
 }
 
-func (ur *userRepository) CreateUser(ctx context.Context, u model.User) (model.User, error) {
+func (ur *userRepository) CreateUser(ctx context.Context, u model.User, outbox ...model.OutboxMessage) (model.User, error) {
 	ur.log.InfoWithID(ctx, "[Repository: CreateUser] Called for email:", u.Email)
-	if err := ur.db.Create(&u).Error; err != nil {
+	err := ur.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+	if err != nil {
 		ur.log.ErrorWithID(ctx, "[Repository: CreateUser] Error creating user:", err)
 		return model.User{}, err
 	}
@@ -44,7 +51,7 @@ func (ur *userRepository) CreateUser(ctx context.Context, u model.User) (model.U
 func (ur *userRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
 	ur.log.InfoWithID(ctx, "[Repository: FindByEmail] Called for email:", email)
 	var user model.User
-	if err := ur.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := ur.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			ur.log.ErrorWithID(ctx, "[Repository: FindByEmail] User not found for email:", email)
 			return model.User{}, gorm.ErrRecordNotFound
@@ -60,7 +67,7 @@ func (ur *userRepository) FindByID(ctx context.Context, id string) (model.User,
 	ur.log.InfoWithID(ctx, "[Repository: FindByID] Called for id:", id)
 	var user model.User
 	// Use a where clause to find by the user_id column.
-	if err := ur.db.First(&user, "user_id = ?", id).Error; err != nil {
+	if err := ur.db.WithContext(ctx).First(&user, "user_id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			ur.log.ErrorWithID(ctx, "[Repository: FindByID] User not found with id:", id)
 			return model.User{}, gorm.ErrRecordNotFound
@@ -74,7 +81,7 @@ func (ur *userRepository) FindByID(ctx context.Context, id string) (model.User,
 
 func (ur *userRepository) UpdateUser(ctx context.Context, u model.User) (model.User, error) {
 	ur.log.InfoWithID(ctx, "[Repository: UpdateUser] Called for id:", u.UserID)
-	if err := ur.db.Save(&u).Error; err != nil {
+	if err := ur.db.WithContext(ctx).Save(&u).Error; err != nil {
 		ur.log.ErrorWithID(ctx, "[Repository: UpdateUser] Error updating user:", err)
 		return model.User{}, err
 	}
@@ -84,7 +91,7 @@ func (ur *userRepository) UpdateUser(ctx context.Context, u model.User) (model.U
 
 func (ur *userRepository) DeleteUser(ctx context.Context, id string) error {
 	ur.log.InfoWithID(ctx, "[Repository: DeleteUser] Called with id:", id)
-	if err := ur.db.Delete(&model.User{}, "user_id = ?", id).Error; err != nil {
+	if err := ur.db.WithContext(ctx).Delete(&model.User{}, "user_id = ?", id).Error; err != nil {
 		ur.log.ErrorWithID(ctx, "[Repository: DeleteUser] Error deleting user:", err)
 		return err
 	}
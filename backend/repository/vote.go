@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VoteFilter narrows a user's vote history. From and To are inclusive poll
+// days formatted YYYY-MM-DD; empty means unbounded.
+type VoteFilter struct {
+	From   string
+	To     string
+	Limit  int
+	Offset int
+}
+
+// VoteRepository stores the option each user backs in each poll.
+type VoteRepository interface {
+	// UpsertVote records v, replacing the user's earlier vote in the same poll
+	// unless that one is newer.
+	UpsertVote(ctx context.Context, v model.Vote) error
+	// DeleteVote removes the user's vote if it was cast before votedBefore, so
+	// a retract never removes a newer vote written ahead of it.
+	DeleteVote(ctx context.Context, userID, questionID uuid.UUID, votedBefore time.Time) error
+	// ReplaceVotes makes votes the whole history of questionID. Votes already
+	// stored keep their voted_at.
+	ReplaceVotes(ctx context.Context, questionID uuid.UUID, votes []model.Vote) error
+	// FindByUser lists the votes of userID, most recent poll first.
+	FindByUser(ctx context.Context, userID uuid.UUID, filter VoteFilter) ([]model.Vote, error)
+}
+
+type voteRepository struct {
+	db  *gorm.DB
+	log log.LoggerInterface
+}
+
+// NewVoteRepository creates a new voteRepository with injected DB and logger.
+func NewVoteRepository(db *gorm.DB, logger log.LoggerInterface) VoteRepository {
+	return &voteRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (vr *voteRepository) UpsertVote(ctx context.Context, v model.Vote) error {
+	vr.log.InfoWithID(ctx, "[Repository: UpsertVote] Called for question:", v.QuestionID)
+	// Writes behind Redis can arrive out of order; the latest vote wins.
+	err := vr.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "question_id"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "votes.voted_at < excluded.voted_at"}}},
+		DoUpdates: clause.AssignmentColumns([]string{"option_id", "option_text", "voted_at"}),
+	}).Create(&v).Error
+	if err != nil {
+		vr.log.ErrorWithID(ctx, "[Repository: UpsertVote] Error saving vote:", err)
+		return err
+	}
+	return nil
+}
+
+func (vr *voteRepository) DeleteVote(ctx context.Context, userID, questionID uuid.UUID, votedBefore time.Time) error {
+	vr.log.InfoWithID(ctx, "[Repository: DeleteVote] Called for question:", questionID)
+	err := vr.db.WithContext(ctx).
+		Where("user_id = ? AND question_id = ? AND voted_at <= ?", userID, questionID, votedBefore).
+		Delete(&model.Vote{}).Error
+	if err != nil {
+		vr.log.ErrorWithID(ctx, "[Repository: DeleteVote] Error deleting vote:", err)
+		return err
+	}
+	return nil
+}
+
+func (vr *voteRepository) ReplaceVotes(ctx context.Context, questionID uuid.UUID, votes []model.Vote) error {
+	vr.log.InfoWithID(ctx, "[Repository: ReplaceVotes] Called for question:", questionID, "votes:", len(votes))
+	err := vr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := make([]uuid.UUID, len(votes))
+		for i, v := range votes {
+			users[i] = v.UserID
+		}
+		stale := tx.Where("question_id = ?", questionID)
+		if len(users) > 0 {
+			stale = stale.Where("user_id NOT IN ?", users)
+		}
+		if err := stale.Delete(&model.Vote{}).Error; err != nil {
+			return err
+		}
+		if len(votes) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "question_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"option_id", "option_text", "question_text"}),
+		}).Create(&votes).Error
+	})
+	if err != nil {
+		vr.log.ErrorWithID(ctx, "[Repository: ReplaceVotes] Error replacing votes:", err)
+		return err
+	}
+	return nil
+}
+
+func (vr *voteRepository) FindByUser(ctx context.Context, userID uuid.UUID, filter VoteFilter) ([]model.Vote, error) {
+	vr.log.InfoWithID(ctx, "[Repository: FindVotesByUser] Called for user:", userID)
+	query := vr.db.WithContext(ctx).Where("user_id = ?", userID)
+	if filter.From != "" {
+		query = query.Where("poll_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("poll_date <= ?", filter.To)
+	}
+
+	var votes []model.Vote
+	err := query.
+		Order("poll_date DESC").
+		Order("voted_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&votes).Error
+	if err != nil {
+		vr.log.ErrorWithID(ctx, "[Repository: FindVotesByUser] Error retrieving votes:", err)
+		return nil, err
+	}
+	return votes, nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+// IArchiverService persists a day's Redis polls into the questions table.
+type IArchiverService interface {
+	// ArchiveDate copies every poll listed in questions:<date> into Postgres
+	// and reconciles its vote history with Redis. It is safe to call
+	// repeatedly for the same date, and returns repository.ErrArchiveLocked
+	// while another instance is archiving.
+	ArchiveDate(ctx context.Context, date string) (int, error)
+	// Start archives the two previous days once per night until ctx is
+	// cancelled.
+	Start(ctx context.Context)
+}
+
+type ArchiverService struct {
+	repo  repository.QuestionRepository
+	votes repository.VoteRepository
+	cache db.CacheService
+	log   log.LoggerInterface
+}
+
+// NewArchiverService creates a new ArchiverService with injected repositories, cache and logger.
+func NewArchiverService(r repository.QuestionRepository, votes repository.VoteRepository, cache db.CacheService, logger log.LoggerInterface) IArchiverService {
+	return &ArchiverService{
+		repo:  r,
+		votes: votes,
+		cache: cache,
+		log:   logger,
+	}
+}
+
+func (as *ArchiverService) ArchiveDate(ctx context.Context, date string) (int, error) {
+	as.log.InfoWithID(ctx, "[Service: ArchiveDate] Called for date:", date)
+
+	archiveDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Invalid date:", err)
+		return 0, fmt.Errorf("invalid date %q, use YYYY-MM-DD", date)
+	}
+
+	// Every instance runs the nightly job; only the first to get here archives.
+	unlock, err := as.repo.LockArchive(ctx)
+	if err != nil {
+		if errors.Is(err, repository.ErrArchiveLocked) {
+			as.log.InfoWithID(ctx, "[Service: ArchiveDate] Another instance is archiving, skipping:", date)
+		} else {
+			as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Failed to take archive lock:", err)
+		}
+		return 0, err
+	}
+	defer unlock()
+
+	ids, err := as.cache.GetSetMembers(ctx, "questions:"+date)
+	if err != nil {
+		as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Failed to list questions:", err)
+		return 0, err
+	}
+
+	archived := 0
+	var errs []error
+	for _, id := range ids {
+		key := "question:" + date + ":" + id
+		data, err := as.cache.GetAllHash(ctx, key)
+		if err != nil {
+			as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Failed to fetch key:", key, err)
+			errs = append(errs, fmt.Errorf("fetch %s: %w", key, err))
+			continue
+		}
+		if len(data) == 0 {
+			// Deleted from cache before the day ended; nothing to archive.
+			continue
+		}
+
+		if _, closesAt := pollTimes(data); closesAt != nil && time.Now().Before(*closesAt) {
+			// Archived by a later run once the final tallies are in.
+			as.log.InfoWithID(ctx, "[Service: ArchiveDate] Skipping poll that is still open:", key)
+			continue
+		}
+
+		q, err := questionFromCache(archiveDate, data)
+		if err != nil {
+			as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Skipping malformed question:", key, err)
+			errs = append(errs, fmt.Errorf("decode %s: %w", key, err))
+			continue
+		}
+
+		if _, err := as.repo.UpsertQuestion(ctx, q); err != nil {
+			as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Failed to persist question:", q.QuestionID, err)
+			errs = append(errs, fmt.Errorf("persist %s: %w", q.QuestionID, err))
+			continue
+		}
+		archived++
+
+		if err := as.reconcileVotes(ctx, date, q, data); err != nil {
+			as.log.ErrorWithID(ctx, "[Service: ArchiveDate] Failed to reconcile votes:", q.QuestionID, err)
+			errs = append(errs, fmt.Errorf("reconcile votes of %s: %w", q.QuestionID, err))
+		}
+	}
+
+	as.log.InfoWithID(ctx, "[Service: ArchiveDate] Archived questions:", archived, "of", len(ids))
+	return archived, errors.Join(errs...)
+}
+
+func (as *ArchiverService) Start(ctx context.Context) {
+	as.log.InfoWithID(ctx, "[Service: Archiver] Started")
+	for {
+		timer := time.NewTimer(time.Until(nextArchiveRun(time.Now().In(util.Location()))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			as.log.InfoWithID(ctx, "[Service: Archiver] Stopped")
+			return
+		case <-timer.C:
+			// Polls may close up to MaxPollDuration after they open, so the
+			// day before yesterday can hold polls that were still open during
+			// the previous run. Re-archiving a poll only rewrites its tallies.
+			for _, date := range []string{util.DateDaysAgo(2), util.YesterdayDate()} {
+				if _, err := as.ArchiveDate(ctx, date); err != nil && !errors.Is(err, repository.ErrArchiveLocked) {
+					as.log.ErrorWithID(ctx, "[Service: Archiver] Nightly run finished with errors:", date, err)
+				}
+			}
+		}
+	}
+}
+
+// nextArchiveRun returns the next ArchiveRunHour:ArchiveRunMinute after now.
+func nextArchiveRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), constant.ArchiveRunHour, constant.ArchiveRunMinute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// reconcileVotes rewrites the vote history of a closed poll from its
+// votes:<date>:<id> hash, repairing rows the write-behind left stale or
+// missing. Voters from before choices were recorded have no history.
+func (as *ArchiverService) reconcileVotes(ctx context.Context, date string, q model.Question, data map[string]string) error {
+	choices, err := as.cache.GetAllHash(ctx, voteKeys(date, q.QuestionID.String()).Votes)
+	if err != nil {
+		return err
+	}
+	options, err := cacheOptions(data)
+	if err != nil {
+		return err
+	}
+	byField := make(map[string]cacheOption, len(options))
+	for _, option := range options {
+		byField[option.CountField] = option
+	}
+
+	votedAt := time.Now()
+	if q.ClosesAt != nil {
+		votedAt = *q.ClosesAt
+	}
+	votes := make([]model.Vote, 0, len(choices))
+	for userID, field := range choices {
+		user, err := uuid.Parse(userID)
+		if err != nil {
+			continue
+		}
+		option, ok := byField[field]
+		if !ok {
+			continue
+		}
+		votes = append(votes, model.Vote{
+			UserID:       user,
+			QuestionID:   q.QuestionID,
+			OptionID:     option.ID,
+			OptionText:   option.Text,
+			QuestionText: q.QuestionText,
+			PollDate:     q.ArchiveDate,
+			VotedAt:      votedAt,
+		})
+	}
+	return as.votes.ReplaceVotes(ctx, q.QuestionID, votes)
+}
+
+// questionFromCache maps a question:<date>:<id> hash onto the questions table.
+func questionFromCache(archiveDate time.Time, data map[string]string) (model.Question, error) {
+	questionID, err := uuid.Parse(data["question_id"])
+	if err != nil {
+		return model.Question{}, fmt.Errorf("invalid question_id: %w", err)
+	}
+	createdBy, err := uuid.Parse(data["user_id"])
+	if err != nil {
+		return model.Question{}, fmt.Errorf("invalid user_id: %w", err)
+	}
+	cached, err := cacheOptions(data)
+	if err != nil {
+		return model.Question{}, err
+	}
+	if len(cached) < constant.MinQuestionOptions {
+		return model.Question{}, ErrInvalidOptions
+	}
+
+	options := make([]model.QuestionOption, len(cached))
+	for i, option := range cached {
+		optionID, err := uuid.Parse(option.ID)
+		if err != nil {
+			// Legacy two-choice polls use "first_choice"/"second_choice" as
+			// option IDs; derive a stable UUID so re-runs update the same row.
+			optionID = uuid.NewSHA1(questionID, []byte(option.ID))
+		}
+		options[i] = model.QuestionOption{
+			OptionID:   optionID,
+			QuestionID: questionID,
+			Position:   i,
+			OptionText: option.Text,
+			VoteCount:  util.AtoiOrZero(data[option.CountField]),
+		}
+	}
+
+	opensAt, closesAt := pollTimes(data)
+	return model.Question{
+		QuestionID:        questionID,
+		ArchiveDate:       archiveDate,
+		QuestionText:      data["text"],
+		FirstChoice:       options[0].OptionText,
+		SecondChoice:      options[1].OptionText,
+		TotalParticipants: util.AtoiOrZero(data["total_participants"]),
+		FirstChoiceCount:  options[0].VoteCount,
+		SecondChoiceCount: options[1].VoteCount,
+		MarginOfVictory:   model.MarginOfVictory(options),
+		CreatedBy:         createdBy,
+		OpensAt:           opensAt,
+		ClosesAt:          closesAt,
+		Options:           options,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveDateReconcilesVotes(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{})
+	kept := f.vote(t, poll, 0)
+	moved := f.vote(t, poll, 1)
+	missing := f.vote(t, poll, 1)
+
+	question := uuid.MustParse(poll.id)
+	key := func(userID string) testfakes.VoteKey {
+		return testfakes.VoteKey{UserID: uuid.MustParse(userID), QuestionID: question}
+	}
+	votedAt := f.votes.Votes[key(kept)].VotedAt
+	// Simulate write-behind failures: a row on the wrong option, a lost row
+	// and a row for a vote that was retracted in Redis.
+	stale := f.votes.Votes[key(moved)]
+	stale.OptionID, stale.OptionText = poll.options[0].OptionID, poll.options[0].Text
+	f.votes.Votes[key(moved)] = stale
+	delete(f.votes.Votes, key(missing))
+	retracted := uuid.New()
+	f.votes.Votes[key(retracted.String())] = model.Vote{UserID: retracted, QuestionID: question, OptionID: poll.options[0].OptionID}
+
+	date, err := f.service.questionDate(ctx, poll.id)
+	require.NoError(t, err)
+	closed := map[string]string{"closes_at": fmt.Sprint(time.Now().Add(-time.Minute).Unix())}
+	require.NoError(t, f.cache.SetHash(ctx, voteKeys(date, poll.id).Question, closed))
+
+	archiver := NewArchiverService(f.questions, f.votes, f.cache, log.Initialize("test"))
+	archived, err := archiver.ArchiveDate(ctx, date)
+	require.NoError(t, err)
+	require.Equal(t, 1, archived)
+
+	byUser := map[string]model.Vote{}
+	for _, v := range f.votes.ForQuestion(poll.id) {
+		byUser[v.UserID.String()] = v
+	}
+	require.Len(t, byUser, 3)
+	require.Equal(t, "Tea", byUser[kept].OptionText)
+	require.Equal(t, votedAt, byUser[kept].VotedAt)
+	require.Equal(t, "Coffee", byUser[moved].OptionText)
+	require.Equal(t, poll.options[1].OptionID, byUser[missing].OptionID)
+	require.NotContains(t, byUser, retracted.String())
+
+	f.votes.Err = errCacheDown
+	_, err = archiver.ArchiveDate(ctx, date)
+	require.ErrorIs(t, err, errCacheDown)
+}
+
+func TestArchiveDateSkipsWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{})
+	f.vote(t, poll, 0)
+
+	date, err := f.service.questionDate(ctx, poll.id)
+	require.NoError(t, err)
+	closed := map[string]string{"closes_at": fmt.Sprint(time.Now().Add(-time.Minute).Unix())}
+	require.NoError(t, f.cache.SetHash(ctx, voteKeys(date, poll.id).Question, closed))
+
+	// Another instance is archiving.
+	unlock, err := f.questions.LockArchive(ctx)
+	require.NoError(t, err)
+
+	archiver := NewArchiverService(f.questions, f.votes, f.cache, log.Initialize("test"))
+	archived, err := archiver.ArchiveDate(ctx, date)
+	require.ErrorIs(t, err, repository.ErrArchiveLocked)
+	require.Zero(t, archived)
+	require.NotContains(t, f.questions.Questions, uuid.MustParse(poll.id))
+
+	unlock()
+	archived, err = archiver.ArchiveDate(ctx, date)
+	require.NoError(t, err)
+	require.Equal(t, 1, archived)
+	require.Contains(t, f.questions.Questions, uuid.MustParse(poll.id))
+
+	// The lock is released once a run finishes.
+	archived, err = archiver.ArchiveDate(ctx, date)
+	require.NoError(t, err)
+	require.Equal(t, 1, archived)
+}
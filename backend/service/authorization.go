@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// authorize allows actor to act on a resource owned by ownerID when they are
+// the owner or hold one of the privileged roles.
+func authorize(actor entity.Actor, ownerID string, privileged ...string) error {
+	if actor.UserID != "" && actor.UserID == ownerID {
+		return nil
+	}
+	if slices.Contains(privileged, actor.Role) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// Roles that may manage any user account or any question.
+var (
+	userAdminRoles     = []string{constant.RoleAdmin}
+	questionAdminRoles = []string{constant.RoleAdmin, constant.RoleModerator}
+)
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+)
+
+// questionFixture is a QuestionService wired to fakes and an in-memory cache.
+type questionFixture struct {
+	service       *QuestionService
+	cache         *testfakes.FailingCache
+	questions     *testfakes.QuestionRepo
+	scheduled     *testfakes.ScheduledRepo
+	votes         *testfakes.VoteRepo
+	users         *testfakes.UserRepo
+	notifications *testfakes.NotificationService
+	user          model.User
+	admin         model.User
+}
+
+func newQuestionFixture(t *testing.T) *questionFixture {
+	t.Helper()
+	logger := log.Initialize("test")
+	f := &questionFixture{
+		cache:         testfakes.NewFailingCache(),
+		questions:     testfakes.NewQuestionRepo(),
+		scheduled:     testfakes.NewScheduledRepo(),
+		votes:         testfakes.NewVoteRepo(),
+		notifications: &testfakes.NotificationService{},
+		user:          model.User{UserID: uuid.New(), Email: "user@example.com", Role: constant.RoleUser},
+		admin:         model.User{UserID: uuid.New(), Email: "admin@example.com", Role: constant.RoleAdmin},
+	}
+	f.users = testfakes.NewUserRepo(f.user, f.admin)
+	f.service = NewQuestionService(f.questions, f.scheduled, f.votes, f.cache, logger, NewUserService(f.users, logger), f.notifications).(*QuestionService)
+	return f
+}
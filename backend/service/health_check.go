@@ -1,18 +1,48 @@
 package service
 
-import "github.com/guncv/Poll-Voting-Website/backend/entity"
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+)
 
 // HealthCheckService defines the interface for health check operations.
 type HealthCheckService interface {
+	// HealthCheck reports that the process is up, without touching any
+	// dependency.
 	HealthCheck() entity.HealthCheckResponse
+	// Ready checks every dependency the API needs to serve requests.
+	Ready(ctx context.Context) entity.ReadinessResponse
 }
 
 // healthCheckService is the concrete implementation of HealthCheckService.
-type healthCheckService struct{}
+type healthCheckService struct {
+	repo       repository.HealthCheckRepository
+	cache      db.CacheService
+	outboxRepo repository.IOutboxRepository
+	log        log.LoggerInterface
+}
+
+// errDeadNotifications marks notifications the dispatcher gave up on, which
+// usually means a notification channel is failing.
+var errDeadNotifications = errors.New("undelivered notifications in the outbox")
 
 // NewHealthCheckService creates a new instance of HealthCheckService.
-func NewHealthCheckService() HealthCheckService {
-	return &healthCheckService{}
+func NewHealthCheckService(repo repository.HealthCheckRepository, cache db.CacheService, outboxRepo repository.IOutboxRepository, logger log.LoggerInterface) HealthCheckService {
+	return &healthCheckService{
+		repo:       repo,
+		cache:      cache,
+		outboxRepo: outboxRepo,
+		log:        logger,
+	}
 }
 
 // HealthCheck returns a health check response.
@@ -22,3 +52,80 @@ func (s *healthCheckService) HealthCheck() entity.HealthCheckResponse {
 		Message: "API is healthy!",
 	}
 }
+
+type dependencyCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+// Ready runs every check concurrently, each bounded by
+// constant.HealthCheckTimeout. Postgres and Redis are critical; the
+// notification check only degrades the response.
+func (s *healthCheckService) Ready(ctx context.Context) entity.ReadinessResponse {
+	checks := []dependencyCheck{
+		{name: "postgres", critical: true, check: s.repo.PingDatabase},
+		{name: "redis", critical: true, check: s.cache.Ping},
+		{name: "notifications", check: s.checkNotifications},
+	}
+
+	results := make([]entity.DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, dc := range checks {
+		wg.Add(1)
+		go func(i int, dc dependencyCheck) {
+			defer wg.Done()
+			results[i] = s.runCheck(ctx, dc)
+		}(i, dc)
+	}
+	wg.Wait()
+
+	resp := entity.ReadinessResponse{
+		Status: entity.HealthStatusOK,
+		Checks: make(map[string]entity.DependencyStatus, len(checks)),
+	}
+	for i, dc := range checks {
+		result := results[i]
+		resp.Checks[dc.name] = result
+		if result.Status == entity.HealthStatusOK {
+			continue
+		}
+		if dc.critical {
+			resp.Status = entity.HealthStatusUnavailable
+		} else if resp.Status == entity.HealthStatusOK {
+			resp.Status = entity.HealthStatusDegraded
+		}
+	}
+	return resp
+}
+
+func (s *healthCheckService) runCheck(ctx context.Context, dc dependencyCheck) entity.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, constant.HealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dc.check(ctx)
+	result := entity.DependencyStatus{
+		Status:    entity.HealthStatusOK,
+		Critical:  dc.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		s.log.ErrorWithID(ctx, "[Service: Ready] Check failed:", dc.name, err)
+		result.Status = entity.HealthStatusUnavailable
+	}
+	return result
+}
+
+// checkNotifications fails while dead messages wait in the outbox. The
+// channels themselves are not contacted so readiness probes never send mail.
+func (s *healthCheckService) checkNotifications(ctx context.Context) error {
+	dead, err := s.outboxRepo.FindByStatus(ctx, model.OutboxStatusDead, 1)
+	if err != nil {
+		return err
+	}
+	if len(dead) > 0 {
+		return errDeadNotifications
+	}
+	return nil
+}
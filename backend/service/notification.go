@@ -2,46 +2,89 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"slices"
+	"strings"
 
 	"github.com/guncv/Poll-Voting-Website/backend/entity"
 	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
 	"github.com/guncv/Poll-Voting-Website/backend/repository"
 )
 
+// INotificationService queues notifications in the outbox and delivers them
+// on behalf of the outbox dispatcher.
 type INotificationService interface {
-	SendAlertReachParticipantsToAdmin(ctx context.Context, questionText string, totalParticipants int, firstChoice, secondChoice string, firstChoiceCount, secondChoiceCount int) error
+	SendAlertReachParticipantsToAdmin(ctx context.Context, questionText string, totalParticipants int, options []entity.QuestionOption) error
 	NotifyUserOfAdminQuestion(ctx context.Context, email, subject, message string) error
-	AddSubscriberToUserTopic(ctx context.Context, email string) error
-	CheckIsAdmin(ctx context.Context, email string) (bool, error)
+	// Deliver sends one outbox message through the configured channels.
+	Deliver(ctx context.Context, msg model.OutboxMessage) error
 }
 
 type NotificationService struct {
 	sender repository.INotificationRepository
+	outbox repository.IOutboxRepository
 	log    log.LoggerInterface
 }
 
-func NewNotificationService(sender repository.INotificationRepository, logger log.LoggerInterface) INotificationService {
+func NewNotificationService(sender repository.INotificationRepository, outbox repository.IOutboxRepository, logger log.LoggerInterface) INotificationService {
 	return &NotificationService{
 		sender: sender,
+		outbox: outbox,
 		log:    logger,
 	}
 }
 
-func (a *NotificationService) SendAlertReachParticipantsToAdmin(ctx context.Context, questionText string, totalParticipants int, firstChoice, secondChoice string, firstChoiceCount, secondChoiceCount int) error {
+// outboxPayload holds the arguments of every outbox message kind.
+type outboxPayload struct {
+	Subject string `json:"subject,omitempty"`
+	Message string `json:"message,omitempty"`
+	Email   string `json:"email,omitempty"`
+}
+
+func newOutboxMessage(kind string, payload outboxPayload) model.OutboxMessage {
+	// outboxPayload only holds strings, so encoding cannot fail.
+	encoded, _ := json.Marshal(payload)
+	return model.OutboxMessage{Kind: kind, Payload: string(encoded)}
+}
+
+func adminAlertMessage(alert entity.Alert) model.OutboxMessage {
+	return newOutboxMessage(model.OutboxKindAdminAlert, outboxPayload{Subject: alert.Subject, Message: alert.Message})
+}
+
+func userAlertMessage(alert entity.Alert) model.OutboxMessage {
+	return newOutboxMessage(model.OutboxKindUserAlert, outboxPayload{Subject: alert.Subject, Message: alert.Message})
+}
+
+func subscribeUserMessage(email string) model.OutboxMessage {
+	return newOutboxMessage(model.OutboxKindSubscribeUser, outboxPayload{Email: email})
+}
+
+// adminQuestionAlert announces a question created by an admin to all users.
+func adminQuestionAlert(questionText string, choices []string, email string) entity.Alert {
+	return entity.Alert{
+		Subject: "Admin Question",
+		Message: fmt.Sprintf("Question: %s\n%sCreated By: %s", questionText, formatChoices(choices), email),
+	}
+}
+
+func (a *NotificationService) SendAlertReachParticipantsToAdmin(ctx context.Context, questionText string, totalParticipants int, options []entity.QuestionOption) error {
 	a.log.InfoWithID(ctx, "[Service: SendAlertReachParticipantsToAdmin] Called")
+	var message strings.Builder
+	fmt.Fprintf(&message, "Question: %s\nTotal Participants: %d people", questionText, totalParticipants)
+	for i, option := range options {
+		fmt.Fprintf(&message, "\n%d.%s : %d people", i+1, option.Text, option.Count)
+	}
 	alert := entity.Alert{
 		Subject: "Question Reached Participants",
-		Message: fmt.Sprintf("Question: %s\nTotal Participants: %d people\n1.%s : %d people\n2.%s : %d people", questionText, totalParticipants, firstChoice, firstChoiceCount, secondChoice, secondChoiceCount),
+		Message: message.String(),
 	}
 
-	err := a.sender.SendAdminAlert(ctx, alert)
-	if err != nil {
-		a.log.ErrorWithID(ctx, "[Service: SendAlertReachParticipantsToAdmin] Failed to send alert:", err)
+	if err := a.outbox.Enqueue(ctx, adminAlertMessage(alert)); err != nil {
+		a.log.ErrorWithID(ctx, "[Service: SendAlertReachParticipantsToAdmin] Failed to queue alert:", err)
 		return err
 	}
-	a.log.InfoWithID(ctx, "[Service: SendAlertReachParticipantsToAdmin] Alert sent to SNS!")
+	a.log.InfoWithID(ctx, "[Service: SendAlertReachParticipantsToAdmin] Alert queued")
 	return nil
 }
 
@@ -51,32 +94,29 @@ func (a *NotificationService) NotifyUserOfAdminQuestion(ctx context.Context, ema
 		Subject: subject,
 		Message: message,
 	}
-	err := a.sender.SendUserAlert(ctx, alert)
-	if err != nil {
-		a.log.ErrorWithID(ctx, "[Service: NotifyUserOfAdminQuestion] Failed to send alert:", err)
+	if err := a.outbox.Enqueue(ctx, userAlertMessage(alert)); err != nil {
+		a.log.ErrorWithID(ctx, "[Service: NotifyUserOfAdminQuestion] Failed to queue alert:", err)
 		return err
 	}
 	return nil
 }
 
-func (a *NotificationService) CheckIsAdmin(ctx context.Context, email string) (bool, error) {
-	a.log.InfoWithID(ctx, "[Service: CheckIsAdmin] Called")
-	admins, err := a.sender.GetAdminSubscriptions(ctx)
-	if err != nil {
-		a.log.ErrorWithID(ctx, "[Service: CheckIsAdmin] Failed to get admin subscriptions:", err)
-		return false, err
-	}
+func (a *NotificationService) Deliver(ctx context.Context, msg model.OutboxMessage) error {
+	a.log.InfoWithID(ctx, "[Service: DeliverNotification] Called for message:", msg.ID, msg.Kind)
 
-	a.log.InfoWithID(ctx, "[Service: CheckIsAdmin] Found admins:", admins)
-	return slices.Contains(admins, email), nil
-}
+	var payload outboxPayload
+	if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
 
-func (a *NotificationService) AddSubscriberToUserTopic(ctx context.Context, email string) error {
-	a.log.InfoWithID(ctx, "[Service: AddSubscriberToUserTopic] Called")
-	err := a.sender.SubscribeToUserTopic(ctx, email)
-	if err != nil {
-		a.log.ErrorWithID(ctx, "[Service: AddSubscriberToUserTopic] Failed to subscribe:", err)
-		return err
+	switch msg.Kind {
+	case model.OutboxKindAdminAlert:
+		return a.sender.SendAdminAlert(ctx, entity.Alert{Subject: payload.Subject, Message: payload.Message})
+	case model.OutboxKindUserAlert:
+		return a.sender.SendUserAlert(ctx, entity.Alert{Subject: payload.Subject, Message: payload.Message})
+	case model.OutboxKindSubscribeUser:
+		return a.sender.SubscribeToUserTopic(ctx, payload.Email)
+	default:
+		return fmt.Errorf("unknown notification kind %q", msg.Kind)
 	}
-	return nil
 }
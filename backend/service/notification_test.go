@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendAlertReachParticipantsToAdmin(t *testing.T) {
+	ctx := context.Background()
+	options := []entity.QuestionOption{{Text: "Tea", Count: 60}, {Text: "Coffee", Count: 40}}
+
+	outbox := &testfakes.OutboxRepo{}
+	ns := NewNotificationService(&testfakes.NotificationRepo{}, outbox, log.Initialize("test"))
+	require.NoError(t, ns.SendAlertReachParticipantsToAdmin(ctx, "Tea or coffee?", 100, options))
+
+	// The alert is only queued; Deliver sends it to the admins.
+	require.Len(t, outbox.Messages, 1)
+	require.Equal(t, model.OutboxKindAdminAlert, outbox.Messages[0].Kind)
+
+	sender := &testfakes.NotificationRepo{}
+	ns = NewNotificationService(sender, outbox, log.Initialize("test"))
+	require.NoError(t, ns.Deliver(ctx, outbox.Messages[0]))
+	require.Equal(t, []entity.Alert{{
+		Subject: "Question Reached Participants",
+		Message: "Question: Tea or coffee?\nTotal Participants: 100 people\n1.Tea : 60 people\n2.Coffee : 40 people",
+	}}, sender.AdminAlerts)
+	require.Empty(t, sender.UserAlerts)
+
+	outbox.Err = errDatabaseDown
+	require.ErrorIs(t, ns.SendAlertReachParticipantsToAdmin(ctx, "Tea or coffee?", 100, options), errDatabaseDown)
+}
+
+func TestNotifyUserOfAdminQuestion(t *testing.T) {
+	ctx := context.Background()
+	outbox := &testfakes.OutboxRepo{}
+	sender := &testfakes.NotificationRepo{}
+	ns := NewNotificationService(sender, outbox, log.Initialize("test"))
+
+	require.NoError(t, ns.NotifyUserOfAdminQuestion(ctx, "admin@example.com", "Admin Question", "Question: Tea or coffee?"))
+	require.Len(t, outbox.Messages, 1)
+	require.Equal(t, model.OutboxKindUserAlert, outbox.Messages[0].Kind)
+
+	require.NoError(t, ns.Deliver(ctx, outbox.Messages[0]))
+	require.Equal(t, []entity.Alert{{Subject: "Admin Question", Message: "Question: Tea or coffee?"}}, sender.UserAlerts)
+	require.Empty(t, sender.AdminAlerts)
+
+	outbox.Err = errDatabaseDown
+	require.ErrorIs(t, ns.NotifyUserOfAdminQuestion(ctx, "admin@example.com", "Admin Question", "Question"), errDatabaseDown)
+}
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+	alert := entity.Alert{Subject: "Subject", Message: "Message"}
+
+	testCases := []struct {
+		name      string
+		msg       model.OutboxMessage
+		senderErr error
+		wantErr   string
+		check     func(t *testing.T, sender *testfakes.NotificationRepo)
+	}{
+		{
+			name: "admin alert",
+			msg:  adminAlertMessage(alert),
+			check: func(t *testing.T, sender *testfakes.NotificationRepo) {
+				require.Equal(t, []entity.Alert{alert}, sender.AdminAlerts)
+				require.Empty(t, sender.UserAlerts)
+			},
+		},
+		{
+			name: "user alert",
+			msg:  userAlertMessage(alert),
+			check: func(t *testing.T, sender *testfakes.NotificationRepo) {
+				require.Equal(t, []entity.Alert{alert}, sender.UserAlerts)
+				require.Empty(t, sender.AdminAlerts)
+			},
+		},
+		{
+			name: "topic subscription",
+			msg:  subscribeUserMessage("new@example.com"),
+			check: func(t *testing.T, sender *testfakes.NotificationRepo) {
+				require.Equal(t, []string{"new@example.com"}, sender.Subscriptions)
+			},
+		},
+		{name: "channel failure", msg: adminAlertMessage(alert), senderErr: errDatabaseDown, wantErr: errDatabaseDown.Error()},
+		{name: "unknown kind", msg: model.OutboxMessage{Kind: "fax", Payload: "{}"}, wantErr: `unknown notification kind "fax"`},
+		{name: "malformed payload", msg: model.OutboxMessage{Kind: model.OutboxKindAdminAlert, Payload: "{"}, wantErr: "invalid payload: unexpected end of JSON input"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &testfakes.NotificationRepo{Err: tc.senderErr}
+			ns := NewNotificationService(sender, &testfakes.OutboxRepo{}, log.Initialize("test"))
+
+			err := ns.Deliver(ctx, tc.msg)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, sender)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+	"gorm.io/gorm"
+)
+
+// IOutboxService delivers queued notifications and exposes the messages that
+// exhausted their retries.
+type IOutboxService interface {
+	// Start delivers due messages every OutboxPollInterval until ctx is cancelled.
+	Start(ctx context.Context)
+	// DispatchDue delivers one batch of due messages and returns how many were sent.
+	DispatchDue(ctx context.Context) (int, error)
+	ListDeadLetters(ctx context.Context, limit int) ([]model.OutboxMessage, error)
+	// Retry puts a dead message back in the queue.
+	Retry(ctx context.Context, id uuid.UUID) error
+}
+
+var ErrOutboxMessageNotFound = errors.New("dead notification not found")
+
+type OutboxService struct {
+	repo          repository.IOutboxRepository
+	notifications INotificationService
+	log           log.LoggerInterface
+}
+
+// NewOutboxService creates a new OutboxService with injected repository, notification service and logger.
+func NewOutboxService(r repository.IOutboxRepository, notifications INotificationService, logger log.LoggerInterface) IOutboxService {
+	return &OutboxService{
+		repo:          r,
+		notifications: notifications,
+		log:           logger,
+	}
+}
+
+func (obs *OutboxService) Start(ctx context.Context) {
+	obs.log.InfoWithID(ctx, "[Service: OutboxDispatcher] Started")
+	ticker := time.NewTicker(constant.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			obs.log.InfoWithID(ctx, "[Service: OutboxDispatcher] Stopped")
+			return
+		case <-ticker.C:
+			if _, err := obs.DispatchDue(ctx); err != nil {
+				obs.log.ErrorWithID(ctx, "[Service: OutboxDispatcher] Dispatch failed:", err)
+			}
+		}
+	}
+}
+
+func (obs *OutboxService) DispatchDue(ctx context.Context) (int, error) {
+	msgs, err := obs.repo.ClaimDue(ctx, constant.OutboxBatchSize, constant.OutboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, msg := range msgs {
+		if err := obs.notifications.Deliver(ctx, msg); err != nil {
+			dead := msg.Attempts >= constant.OutboxMaxAttempts
+			if dead {
+				obs.log.ErrorWithID(ctx, "[Service: OutboxDispatcher] Giving up on message:", msg.ID, err)
+			} else {
+				obs.log.ErrorWithID(ctx, "[Service: OutboxDispatcher] Delivery failed, will retry:", msg.ID, err)
+			}
+			if err := obs.repo.MarkFailed(ctx, msg.ID, err.Error(), time.Now().Add(outboxBackoff(msg.Attempts)), dead); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := obs.repo.MarkSent(ctx, msg.ID); err != nil {
+			// The lease expires and the message is sent again; channels must
+			// tolerate the occasional duplicate.
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	if len(msgs) > 0 {
+		obs.log.InfoWithID(ctx, "[Service: OutboxDispatcher] Delivered messages:", sent, "of", len(msgs))
+	}
+	return sent, errors.Join(errs...)
+}
+
+// outboxBackoff returns the delay before the next attempt after attempts
+// failed deliveries.
+func outboxBackoff(attempts int) time.Duration {
+	delay := constant.OutboxBaseBackoff
+	for i := 1; i < attempts && delay < constant.OutboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, constant.OutboxMaxBackoff)
+}
+
+func (obs *OutboxService) ListDeadLetters(ctx context.Context, limit int) ([]model.OutboxMessage, error) {
+	obs.log.InfoWithID(ctx, "[Service: ListDeadLetters] Called")
+	return obs.repo.FindByStatus(ctx, model.OutboxStatusDead, limit)
+}
+
+func (obs *OutboxService) Retry(ctx context.Context, id uuid.UUID) error {
+	obs.log.InfoWithID(ctx, "[Service: RetryDeadLetter] Called for id:", id)
+	if err := obs.repo.Requeue(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOutboxMessageNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: constant.OutboxBaseBackoff},
+		{attempts: 2, want: 2 * constant.OutboxBaseBackoff},
+		{attempts: 3, want: 4 * constant.OutboxBaseBackoff},
+		{attempts: 7, want: 64 * constant.OutboxBaseBackoff},
+		{attempts: 8, want: constant.OutboxMaxBackoff},
+		{attempts: 100, want: constant.OutboxMaxBackoff},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, outboxBackoff(tc.attempts), "attempts %d", tc.attempts)
+	}
+}
+
+func TestDispatchDue(t *testing.T) {
+	ctx := context.Background()
+	outbox := &testfakes.OutboxRepo{}
+	sender := &testfakes.NotificationRepo{Err: errDatabaseDown}
+	ns := NewNotificationService(sender, outbox, log.Initialize("test"))
+	obs := NewOutboxService(outbox, ns, log.Initialize("test"))
+
+	require.NoError(t, ns.SendAlertReachParticipantsToAdmin(ctx, "Tea or coffee?", 100, []entity.QuestionOption{{Text: "Tea", Count: 100}}))
+	require.Len(t, outbox.Messages, 1)
+	id := outbox.Messages[0].ID
+
+	// A live message is not dead, so it cannot be retried.
+	require.ErrorIs(t, obs.Retry(ctx, id), ErrOutboxMessageNotFound)
+	require.ErrorIs(t, obs.Retry(ctx, uuid.New()), ErrOutboxMessageNotFound)
+
+	// Every failed attempt backs off further, until the message goes dead.
+	for attempt := 1; attempt <= constant.OutboxMaxAttempts; attempt++ {
+		sent, err := obs.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, sent)
+
+		msg := outbox.Messages[0]
+		require.Equal(t, attempt, msg.Attempts)
+		require.Equal(t, errDatabaseDown.Error(), msg.LastError)
+		require.WithinDuration(t, time.Now().Add(outboxBackoff(attempt)), msg.NextAttemptAt, time.Second)
+		if attempt < constant.OutboxMaxAttempts {
+			require.Equal(t, model.OutboxStatusPending, msg.Status)
+		} else {
+			require.Equal(t, model.OutboxStatusDead, msg.Status)
+		}
+
+		// Nothing is due until the backoff has passed.
+		sent, err = obs.DispatchDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, sent)
+		require.Equal(t, attempt, outbox.Messages[0].Attempts)
+		outbox.Messages[0].NextAttemptAt = time.Now()
+	}
+
+	// Dead messages are left alone until an admin retries them.
+	sent, err := obs.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, sent)
+	require.Equal(t, constant.OutboxMaxAttempts, outbox.Messages[0].Attempts)
+
+	dead, err := obs.ListDeadLetters(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, id, dead[0].ID)
+
+	sender.Err = nil
+	require.NoError(t, obs.Retry(ctx, id))
+	require.Equal(t, model.OutboxStatusPending, outbox.Messages[0].Status)
+	require.Zero(t, outbox.Messages[0].Attempts)
+
+	sent, err = obs.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+	require.Equal(t, model.OutboxStatusSent, outbox.Messages[0].Status)
+	require.NotNil(t, outbox.Messages[0].SentAt)
+	require.Empty(t, outbox.Messages[0].LastError)
+	// The sender saw every failed attempt and the successful one.
+	require.Len(t, sender.AdminAlerts, constant.OutboxMaxAttempts+1)
+
+	// Sent messages are not dead either.
+	require.ErrorIs(t, obs.Retry(ctx, id), ErrOutboxMessageNotFound)
+
+	outbox.Err = errDatabaseDown
+	_, err = obs.DispatchDue(ctx)
+	require.ErrorIs(t, err, errDatabaseDown)
+}
+
+func TestDispatchDueBatchSize(t *testing.T) {
+	ctx := context.Background()
+	outbox := &testfakes.OutboxRepo{}
+	sender := &testfakes.NotificationRepo{}
+	ns := NewNotificationService(sender, outbox, log.Initialize("test"))
+	obs := NewOutboxService(outbox, ns, log.Initialize("test"))
+
+	for i := 0; i <= constant.OutboxBatchSize; i++ {
+		require.NoError(t, ns.NotifyUserOfAdminQuestion(ctx, "admin@example.com", "Admin Question", "Question"))
+	}
+
+	sent, err := obs.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, constant.OutboxBatchSize, sent)
+
+	sent, err = obs.DispatchDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+	require.Len(t, sender.UserAlerts, constant.OutboxBatchSize+1)
+}
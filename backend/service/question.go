@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,41 +17,79 @@ import (
 	"github.com/guncv/Poll-Voting-Website/backend/model"
 	"github.com/guncv/Poll-Voting-Website/backend/repository"
 	"github.com/guncv/Poll-Voting-Website/backend/util"
-	"github.com/mitchellh/mapstructure"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrInvalidOptions   = fmt.Errorf("a poll needs between %d and %d non-empty options", constant.MinQuestionOptions, constant.MaxQuestionOptions)
+	ErrUnknownOption    = errors.New("option not found for this question")
+	ErrPollClosed       = errors.New("this poll is not open for voting")
+	// The cache reports these itself; they are the same errors under the
+	// names controllers use.
+	ErrVotesLocked = db.ErrVotesLocked
+	ErrNoVote      = db.ErrNoVote
+)
+
 // QuestionService defines business operations for questions.
 type IQuestionService interface {
 	//DB question logic
-	CreateQuestion(ctx context.Context, archiveDate time.Time, questionText string, firstChoice string, secondChoice string, totalParticipants int, firstChoiceCount int, secondChoiceCount int, createdBy uuid.UUID) (model.Question, error)
-	GetQuestionByID(ctx context.Context, id int) (model.Question, error)
-	GetAllQuestions(ctx context.Context) ([]model.Question, error)
-	DeleteQuestion(ctx context.Context, id int) error
+	CreateQuestion(ctx context.Context, archiveDate time.Time, questionText string, firstChoice string, secondChoice string, totalParticipants int, firstChoiceCount int, secondChoiceCount int, createdBy uuid.UUID, options []model.QuestionOption) (model.Question, error)
+	GetQuestionByID(ctx context.Context, id uuid.UUID) (model.Question, error)
+	// ListQuestions pages through the archive. cursor is the next_cursor of
+	// the previous page, or empty for the first page.
+	ListQuestions(ctx context.Context, filter repository.QuestionFilter, cursor string) (entity.QuestionPage, error)
+	DeleteQuestion(ctx context.Context, actor entity.Actor, id uuid.UUID) error
 	GetLastArchivedQuestion(ctx context.Context) (model.Question, error)
+	// SearchQuestions finds up to limit archived and up to limit live polls
+	// matching query.
+	SearchQuestions(ctx context.Context, query, viewerID string, limit int) (entity.QuestionSearchResult, error)
 
 	// Redis vote logic
+	// VoteForQuestion casts a vote or, while the poll is open, moves an
+	// earlier vote of the same user to another option.
 	VoteForQuestion(ctx context.Context, vote entity.VoteRequest) (entity.VoteResponse, error)
+	RetractVote(ctx context.Context, userID, questionID string) (entity.VoteResponse, error)
 
 	// New Redis cache logic
-	CreateQuestionCache(ctx context.Context, q entity.CreateQuestionCacheRequest) (string, error)
-	GetQuestionCache(ctx context.Context, questionID string) (model.QuestionCache, error)
-	DeleteQuestionCache(ctx context.Context, questionID string) error
-	GetAllTodayQuestions(ctx context.Context) ([]model.QuestionCache, error)
+	CreateQuestionCache(ctx context.Context, q entity.CreateQuestionCacheRequest) (entity.CreateQuestionCacheResponse, error)
+	// GetQuestionCache returns a live poll with my_vote set to the option
+	// viewerID backs, if any.
+	GetQuestionCache(ctx context.Context, questionID, viewerID string) (model.QuestionCache, error)
+	DeleteQuestionCache(ctx context.Context, actor entity.Actor, questionID string) error
+	GetAllTodayQuestions(ctx context.Context, viewerID string) ([]model.QuestionCache, error)
+	// GetFollowUps reports which follow-ups of a live poll are locked,
+	// pending or unlocked.
+	GetFollowUps(ctx context.Context, questionID string) (entity.FollowUpsResponse, error)
+
+	// Scheduled polls
+	ListScheduledQuestions(ctx context.Context, userID string) ([]entity.ScheduledQuestion, error)
+	// ActivateDueQuestions opens every scheduled poll whose opens_at has passed.
+	ActivateDueQuestions(ctx context.Context) (int, error)
+
+	// Vote history
+	ListUserVotes(ctx context.Context, userID string, filter repository.VoteFilter) (entity.VoteHistory, error)
+
+	// Real-time tallies
+	SubscribeQuestion(ctx context.Context, questionID string) (<-chan entity.VoteResponse, error)
 }
 
 type QuestionService struct {
 	repo                repository.QuestionRepository
+	scheduled           repository.ScheduledQuestionRepository
+	votes               repository.VoteRepository
 	cache               db.CacheService
 	log                 log.LoggerInterface
 	notificationService INotificationService
 	userService         UserService
 }
 
-// NewQuestionService creates a new questionService with injected repository and logger.
-func NewQuestionService(r repository.QuestionRepository, cache db.CacheService, logger log.LoggerInterface, userService UserService, notificationService INotificationService) IQuestionService {
+// NewQuestionService creates a new questionService with injected repositories and logger.
+func NewQuestionService(r repository.QuestionRepository, scheduled repository.ScheduledQuestionRepository, votes repository.VoteRepository, cache db.CacheService, logger log.LoggerInterface, userService UserService, notificationService INotificationService) IQuestionService {
 	return &QuestionService{
 		repo:                r,
+		scheduled:           scheduled,
+		votes:               votes,
 		cache:               cache,
 		log:                 logger,
 		userService:         userService,
@@ -56,25 +97,42 @@ func NewQuestionService(r repository.QuestionRepository, cache db.CacheService,
 	}
 }
 
-func (qs *QuestionService) CreateQuestion(ctx context.Context, archiveDate time.Time, questionText string, firstChoice string, secondChoice string, totalParticipants int, firstChoiceCount int, secondChoiceCount int, createdBy uuid.UUID) (model.Question, error) {
+// CreateQuestion stores an archived question. When options is empty the
+// question is treated as a two-choice poll built from first/second choice.
+func (qs *QuestionService) CreateQuestion(ctx context.Context, archiveDate time.Time, questionText string, firstChoice string, secondChoice string, totalParticipants int, firstChoiceCount int, secondChoiceCount int, createdBy uuid.UUID, options []model.QuestionOption) (model.Question, error) {
 
 	qs.log.InfoWithID(ctx, "[Service: CreateQuestion] Called")
 
+	if len(options) == 0 {
+		options = []model.QuestionOption{
+			{OptionText: firstChoice, VoteCount: firstChoiceCount},
+			{OptionText: secondChoice, VoteCount: secondChoiceCount},
+		}
+	}
+	if len(options) < constant.MinQuestionOptions || len(options) > constant.MaxQuestionOptions {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Invalid number of options:", len(options))
+		return model.Question{}, ErrInvalidOptions
+	}
+	for i := range options {
+		options[i].OptionText = strings.TrimSpace(options[i].OptionText)
+		if options[i].OptionText == "" {
+			qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Empty option at position:", i)
+			return model.Question{}, ErrInvalidOptions
+		}
+		options[i].Position = i
+	}
+
 	q := model.Question{
 		ArchiveDate:       archiveDate,
 		QuestionText:      questionText,
-		FirstChoice:       firstChoice,
-		SecondChoice:      secondChoice,
+		FirstChoice:       options[0].OptionText,
+		SecondChoice:      options[1].OptionText,
 		TotalParticipants: totalParticipants,
-		FirstChoiceCount:  firstChoiceCount,
-		SecondChoiceCount: secondChoiceCount,
+		FirstChoiceCount:  options[0].VoteCount,
+		SecondChoiceCount: options[1].VoteCount,
+		MarginOfVictory:   model.MarginOfVictory(options),
 		CreatedBy:         createdBy,
-	}
-
-	created, err := qs.repo.CreateQuestion(ctx, q)
-	if err != nil {
-		qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Error creating question:", err)
-		return model.Question{}, err
+		Options:           options,
 	}
 
 	user, err := qs.userService.GetUserByID(ctx, createdBy.String())
@@ -83,32 +141,30 @@ func (qs *QuestionService) CreateQuestion(ctx context.Context, archiveDate time.
 		return model.Question{}, err
 	}
 
-	isAdmin, err := qs.notificationService.CheckIsAdmin(ctx, user.Email)
-	if err != nil {
-		qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Error checking if user is admin:", err)
-		return model.Question{}, err
+	// Questions by admins are announced to users through the outbox, written
+	// in the same transaction as the question.
+	var outbox []model.OutboxMessage
+	if user.Role == constant.RoleAdmin {
+		outbox = append(outbox, userAlertMessage(adminQuestionAlert(q.QuestionText, questionOptionTexts(options), user.Email)))
 	}
 
-	if isAdmin {
-		questionAlert := fmt.Sprintf("Question: %s\nFirst Choice: %s\nSecond Choice: %s\nCreated By: %s", q.QuestionText, q.FirstChoice, q.SecondChoice, user.Email)
-		err = qs.notificationService.NotifyUserOfAdminQuestion(ctx, user.Email, "Admin Question", questionAlert)
-		if err != nil {
-			qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Error notifying user of admin question:", err)
-			return model.Question{}, err
-		}
+	created, err := qs.repo.CreateQuestion(ctx, q, outbox...)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestion] Error creating question:", err)
+		return model.Question{}, err
 	}
 
 	qs.log.InfoWithID(ctx, "[Service: CreateQuestion] Question created with id:", created.QuestionID)
 	return created, nil
 }
 
-func (qs *QuestionService) GetQuestionByID(ctx context.Context, id int) (model.Question, error) {
+func (qs *QuestionService) GetQuestionByID(ctx context.Context, id uuid.UUID) (model.Question, error) {
 	qs.log.InfoWithID(ctx, "[Service: GetQuestionByID] Called for id:", id)
 	q, err := qs.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			qs.log.ErrorWithID(ctx, "[Service: GetQuestionByID] Question not found with id:", id)
-			return model.Question{}, errors.New("question not found")
+			return model.Question{}, ErrQuestionNotFound
 		}
 		qs.log.ErrorWithID(ctx, "[Service: GetQuestionByID] Error finding question:", err)
 		return model.Question{}, err
@@ -117,30 +173,61 @@ func (qs *QuestionService) GetQuestionByID(ctx context.Context, id int) (model.Q
 	return q, nil
 }
 
-func (qs *QuestionService) GetAllQuestions(ctx context.Context) ([]model.Question, error) {
-	qs.log.InfoWithID(ctx, "[Service: GetAllQuestions] Called")
-	questions, err := qs.repo.FindAll(ctx)
+func (qs *QuestionService) ListQuestions(ctx context.Context, filter repository.QuestionFilter, cursor string) (entity.QuestionPage, error) {
+	qs.log.InfoWithID(ctx, "[Service: ListQuestions] Called with sort:", filter.Sort)
+	if cursor != "" {
+		after, err := decodeQuestionCursor(filter, cursor)
+		if err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: ListQuestions] Invalid cursor:", cursor)
+			return entity.QuestionPage{}, err
+		}
+		filter.After = after
+	}
+
+	// Ask for one extra row to learn whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	questions, err := qs.repo.FindPage(ctx, filter)
 	if err != nil {
-		qs.log.ErrorWithID(ctx, "[Service: GetAllQuestions] Error retrieving questions:", err)
-		return nil, err
+		qs.log.ErrorWithID(ctx, "[Service: ListQuestions] Error retrieving questions:", err)
+		return entity.QuestionPage{}, err
+	}
+
+	page := entity.QuestionPage{Questions: questions}
+	if len(questions) > limit {
+		page.Questions = questions[:limit]
+		next := encodeQuestionCursor(filter, page.Questions[limit-1])
+		page.NextCursor = &next
 	}
-	qs.log.InfoWithID(ctx, "[Service: GetAllQuestions] Retrieved questions successfully")
-	return questions, nil
+	if page.Questions == nil {
+		page.Questions = []model.Question{}
+	}
+	qs.log.InfoWithID(ctx, "[Service: ListQuestions] Retrieved questions:", len(page.Questions))
+	return page, nil
 }
 
-func (qs *QuestionService) DeleteQuestion(ctx context.Context, id int) error {
+// DeleteQuestion removes an archived question. Only its creator, a moderator
+// or an admin may do so.
+func (qs *QuestionService) DeleteQuestion(ctx context.Context, actor entity.Actor, id uuid.UUID) error {
 	qs.log.InfoWithID(ctx, "[Service: DeleteQuestion] Called for id:", id)
 	// Verify question exists.
-	_, err := qs.repo.FindByID(ctx, id)
+	q, err := qs.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			qs.log.ErrorWithID(ctx, "[Service: DeleteQuestion] Question not found with id:", id)
-			return errors.New("question not found")
+			return ErrQuestionNotFound
 		}
 		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestion] Error finding question:", err)
 		return err
 	}
+	if err := authorize(actor, q.CreatedBy.String(), questionAdminRoles...); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestion] Forbidden for actor:", actor.UserID)
+		return err
+	}
 	if err := qs.repo.DeleteQuestion(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionNotFound
+		}
 		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestion] Error deleting question:", err)
 		return err
 	}
@@ -149,177 +236,631 @@ func (qs *QuestionService) DeleteQuestion(ctx context.Context, id int) error {
 }
 
 func (qs *QuestionService) VoteForQuestion(ctx context.Context, vote entity.VoteRequest) (entity.VoteResponse, error) {
-	date := util.TodayDate()
 	qs.log.InfoWithID(ctx, "[Service: VoteForQuestion] Called for qid:", vote.QuestionID)
 
-	voteKey := "voted:" + date + ":" + vote.QuestionID
-	if voted, _ := qs.cache.IsSetMember(voteKey, vote.UserID); voted {
-		qs.log.InfoWithID(ctx, "[Service: VoteForQuestion] User already voted")
-		return entity.VoteResponse{AlreadyVoted: true, QuestionID: vote.QuestionID}, nil
+	date, err := qs.questionDate(ctx, vote.QuestionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error resolving poll day:", err)
+		return entity.VoteResponse{}, err
+	}
+	questionKey := "question:" + date + ":" + vote.QuestionID
+	current, err := qs.cache.GetAllHash(ctx, questionKey)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error reading question:", err)
+		return entity.VoteResponse{}, err
+	}
+	if len(current) == 0 {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Question not found:", vote.QuestionID)
+		return entity.VoteResponse{}, ErrQuestionNotFound
 	}
 
-	qs.cache.AddSetMember(voteKey, vote.UserID)
-
-	// Update vote counters
-	field := "first_choice_count"
-	if !vote.IsFirstChoice {
-		field = "second_choice_count"
+	options, err := cacheOptions(current)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error decoding options:", err)
+		return entity.VoteResponse{}, err
+	}
+	option, err := selectOption(options, vote)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Invalid option:", vote.OptionID)
+		return entity.VoteResponse{}, err
 	}
-	qs.cache.IncrementField("question:"+date+":"+vote.QuestionID, field)
-	total := qs.cache.IncrementField("question:"+date+":"+vote.QuestionID, "total_participants")
 
-	// Check milestone logic
-	milestoneStr, _ := qs.cache.GetField("question:"+date+":"+vote.QuestionID, "milestones")
-	revealedKey := "revealed:" + vote.QuestionID
-	newlyRevealed := []string{}
+	// Dedup, counters and milestone reveals happen atomically in Redis.
+	result, err := qs.cache.RecordVote(ctx, voteKeys(date, vote.QuestionID), vote.UserID, option.CountField, constant.ParticipantsReachedThreshold)
+	if err != nil {
+		if errors.Is(err, db.ErrKeyNotFound) {
+			qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Question expired while voting:", vote.QuestionID)
+			return entity.VoteResponse{}, ErrQuestionNotFound
+		}
+		if errors.Is(err, db.ErrVotingClosed) {
+			qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Poll is not open:", vote.QuestionID)
+			return entity.VoteResponse{}, ErrPollClosed
+		}
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error recording vote:", err)
+		return entity.VoteResponse{}, err
+	}
+	if result.AlreadyVoted {
+		qs.log.InfoWithID(ctx, "[Service: VoteForQuestion] User already voted")
+		return entity.VoteResponse{AlreadyVoted: true, QuestionID: vote.QuestionID}, nil
+	}
 
-	milestones := util.ParseMilestones(milestoneStr) // map[int]string
+	q, err := questionCacheFromHash(result.Question)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error decoding question:", err)
+		return entity.VoteResponse{}, err
+	}
 
-	for threshold, followUpID := range milestones {
-		if total >= int64(threshold) {
-			isRevealed, _ := qs.cache.IsSetMember(revealedKey, fmt.Sprint(threshold))
-			if !isRevealed {
-				qs.cache.AddSetMember(revealedKey, fmt.Sprint(threshold))
-				newlyRevealed = append(newlyRevealed, followUpID)
+	tallies := voteOptions(q.Options)
+	if result.ThresholdReached {
+		// The vote is already counted; a notification problem must not fail
+		// it. Clearing alerted hands the alert to the next vote instead.
+		if err := qs.notificationService.SendAlertReachParticipantsToAdmin(ctx, q.Text, q.TotalParticipants, tallies); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error queueing alert to admin:", err)
+			if err := qs.cache.DeleteField(ctx, questionKey, "alerted"); err != nil {
+				qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error re-arming admin alert:", err)
 			}
 		}
 	}
+	if len(result.NewlyRevealed) > 0 {
+		// Follow-ups that fail to open here are opened by the scheduler.
+		if err := qs.unlockFollowUps(ctx, result.NewlyRevealed); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error unlocking follow-ups:", err)
+		}
+	}
 
-	question, _ := qs.cache.GetAllHash("question:" + date + ":" + vote.QuestionID)
-	var q entity.QuestionCache
-	decoderConfig := &mapstructure.DecoderConfig{
-		TagName:          "json",
-		WeaklyTypedInput: true,
-		Result:           &q,
+	resp := entity.VoteResponse{
+		QuestionID:        vote.QuestionID,
+		FirstChoiceCount:  q.FirstChoiceCount,
+		SecondChoiceCount: q.SecondChoiceCount,
+		Options:           tallies,
+		TotalParticipants: q.TotalParticipants,
+		NewlyRevealedIDs:  result.NewlyRevealed,
+		AlreadyVoted:      false,
+		Changed:           result.Changed,
 	}
-	decoder, err := mapstructure.NewDecoder(decoderConfig)
-	if err != nil {
-		return entity.VoteResponse{}, err
+
+	// Stream subscribers and the vote history only miss this vote if these
+	// fail; the vote itself is already counted, so do not fail the request.
+	if err := qs.publishTally(ctx, resp); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error publishing tally:", err)
 	}
-	if err := decoder.Decode(question); err != nil {
-		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error decoding question:", err)
+	if err := qs.saveVote(ctx, vote.UserID, vote.QuestionID, date, q.Text, option); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error saving vote history:", err)
+	}
+
+	return resp, nil
+}
+
+// RetractVote withdraws the vote of userID while the poll is open.
+func (qs *QuestionService) RetractVote(ctx context.Context, userID, questionID string) (entity.VoteResponse, error) {
+	qs.log.InfoWithID(ctx, "[Service: RetractVote] Called for qid:", questionID)
+
+	date, err := qs.questionDate(ctx, questionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: RetractVote] Error resolving poll day:", err)
 		return entity.VoteResponse{}, err
 	}
 
-	if q.TotalParticipants == constant.ParticipantsReachedThreshold {
-		if err := qs.notificationService.SendAlertReachParticipantsToAdmin(ctx, q.Text, q.TotalParticipants, q.FirstChoice, q.SecondChoice, q.FirstChoiceCount, q.SecondChoiceCount); err != nil {
-			qs.log.ErrorWithID(ctx, "[Service: VoteForQuestion] Error sending alert to admin:", err)
+	result, err := qs.cache.RetractVote(ctx, voteKeys(date, questionID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrKeyNotFound):
+			qs.log.ErrorWithID(ctx, "[Service: RetractVote] Question not found:", questionID)
+			return entity.VoteResponse{}, ErrQuestionNotFound
+		case errors.Is(err, db.ErrVotingClosed):
+			qs.log.ErrorWithID(ctx, "[Service: RetractVote] Poll is not open:", questionID)
+			return entity.VoteResponse{}, ErrPollClosed
+		case errors.Is(err, ErrVotesLocked):
+			qs.log.ErrorWithID(ctx, "[Service: RetractVote] Votes are locked:", questionID)
+			return entity.VoteResponse{}, err
+		case errors.Is(err, ErrNoVote):
+			qs.log.ErrorWithID(ctx, "[Service: RetractVote] No vote to retract:", questionID)
 			return entity.VoteResponse{}, err
 		}
+		qs.log.ErrorWithID(ctx, "[Service: RetractVote] Error retracting vote:", err)
+		return entity.VoteResponse{}, err
 	}
 
-	return entity.VoteResponse{
-		QuestionID:        vote.QuestionID,
+	q, err := questionCacheFromHash(result.Question)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: RetractVote] Error decoding question:", err)
+		return entity.VoteResponse{}, err
+	}
+
+	resp := entity.VoteResponse{
+		QuestionID:        questionID,
 		FirstChoiceCount:  q.FirstChoiceCount,
 		SecondChoiceCount: q.SecondChoiceCount,
-		TotalParticipants: int(total),
-		NewlyRevealedIDs:  newlyRevealed,
-		AlreadyVoted:      false,
-	}, nil
+		Options:           voteOptions(q.Options),
+		TotalParticipants: q.TotalParticipants,
+		NewlyRevealedIDs:  []string{},
+	}
+	if err := qs.publishTally(ctx, resp); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: RetractVote] Error publishing tally:", err)
+	}
+	if err := qs.deleteVote(ctx, userID, questionID, time.Now()); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: RetractVote] Error removing vote history:", err)
+	}
+	return resp, nil
+}
+
+// saveVote writes a counted vote behind Redis into the votes table.
+func (qs *QuestionService) saveVote(ctx context.Context, userID, questionID, date, questionText string, option cacheOption) error {
+	user, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	question, err := uuid.Parse(questionID)
+	if err != nil {
+		return err
+	}
+	pollDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return err
+	}
+	return qs.votes.UpsertVote(ctx, model.Vote{
+		UserID:       user,
+		QuestionID:   question,
+		OptionID:     option.ID,
+		OptionText:   option.Text,
+		QuestionText: questionText,
+		PollDate:     pollDate,
+		VotedAt:      time.Now(),
+	})
+}
+
+// deleteVote removes the history row of a vote retracted at retractedAt. Rows
+// the write-behind gets wrong are repaired when the archiver reconciles the
+// poll.
+func (qs *QuestionService) deleteVote(ctx context.Context, userID, questionID string, retractedAt time.Time) error {
+	user, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	question, err := uuid.Parse(questionID)
+	if err != nil {
+		return err
+	}
+	return qs.votes.DeleteVote(ctx, user, question, retractedAt)
+}
+
+// viewerVote returns the ID of the option viewerID backs in the poll keyed
+// under date, or "" when they have not voted. Votes from before choices were
+// recorded are unknown.
+func (qs *QuestionService) viewerVote(ctx context.Context, date, questionID, viewerID string, data map[string]string) (string, error) {
+	if viewerID == "" || len(data) == 0 {
+		return "", nil
+	}
+	field, err := qs.cache.GetField(ctx, voteKeys(date, questionID).Votes, viewerID)
+	if err != nil || field == "" {
+		return "", err
+	}
+	options, err := cacheOptions(data)
+	if err != nil {
+		return "", err
+	}
+	for _, option := range options {
+		if option.CountField == field {
+			return option.ID, nil
+		}
+	}
+	return "", nil
 }
 
-func (qs *QuestionService) CreateQuestionCache(ctx context.Context, req entity.CreateQuestionCacheRequest) (string, error) {
-    id := uuid.New().String()
-    date := util.TodayDate()
-    key := "question:" + date + ":" + id
-
-    qs.log.InfoWithID(ctx, "[Service: CreateQuestionCache] Called for key:", key)
-
-    data := map[string]string{
-        "question_id":         id,
-        "user_id":             req.UserID,
-        "text":                req.Text,
-        "first_choice":        req.FirstChoice,
-        "second_choice":       req.SecondChoice,
-        "first_choice_count":  "0",
-        "second_choice_count": "0",
-        "total_participants":  "0",
-        "milestones":          req.Milestones,
-        "follow_ups":          req.FollowUps,
-        "group_id":            req.GroupID,
-    }
-
-    if err := qs.cache.SetHash(key, data); err != nil {
-        qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Failed to store in Redis:", err)
-        return "", err
-    }
-
-    if err := qs.cache.AddToSet("questions:"+date, id); err != nil {
-        return "", err
-    }
-
-    now := time.Now()
-    endOfDay := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
-    ttl := time.Until(endOfDay)
-
-    _ = qs.cache.SetTTL(key, ttl)                 // expire question:<date>:<id>
-    _ = qs.cache.SetTTL("questions:"+date, ttl)   // expire questions:<date> set
-
-    // Notify if admin
-    user, err := qs.userService.GetUserByID(ctx, req.UserID)
-    if err != nil {
-        qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Error getting user:", err)
-        return "", err
-    }
-
-    isAdmin, err := qs.notificationService.CheckIsAdmin(ctx, user.Email)
-    if err != nil {
-        qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Error checking if user is admin:", err)
-        return "", err
-    }
-
-    if isAdmin {
-        questionAlert := fmt.Sprintf("Question: %s\nFirst Choice: %s\nSecond Choice: %s\nCreated By: %s", req.Text, req.FirstChoice, req.SecondChoice, user.Email)
-        err = qs.notificationService.NotifyUserOfAdminQuestion(ctx, user.Email, "Admin Question", questionAlert)
-        if err != nil {
-            qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Error notifying user of admin question:", err)
-            return "", err
-        }
-    }
-
-    return id, nil
+// ListUserVotes pages through the polls userID has voted in, most recent
+// poll day first.
+func (qs *QuestionService) ListUserVotes(ctx context.Context, userID string, filter repository.VoteFilter) (entity.VoteHistory, error) {
+	qs.log.InfoWithID(ctx, "[Service: ListUserVotes] Called for user:", userID)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return entity.VoteHistory{}, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	// Ask for one extra row to learn whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	votes, err := qs.votes.FindByUser(ctx, id, filter)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: ListUserVotes] Error retrieving votes:", err)
+		return entity.VoteHistory{}, err
+	}
+
+	history := entity.VoteHistory{Votes: votes}
+	if len(votes) > limit {
+		history.Votes = votes[:limit]
+		next := filter.Offset + limit
+		history.NextOffset = &next
+	}
+	if history.Votes == nil {
+		history.Votes = []model.Vote{}
+	}
+	return history, nil
 }
 
+// voteKeys names the Redis keys a vote on the poll keyed under date touches.
+func voteKeys(date, questionID string) db.VoteKeys {
+	return db.VoteKeys{
+		Question:     "question:" + date + ":" + questionID,
+		Votes:        "votes:" + date + ":" + questionID,
+		LegacyVoters: "voted:" + date + ":" + questionID,
+		Revealed:     "revealed:" + questionID,
+	}
+}
+
+// CreateQuestionCache opens a poll in Redis right away or, when opens_at lies
+// in the future, stores it for the scheduler.
+func (qs *QuestionService) CreateQuestionCache(ctx context.Context, req entity.CreateQuestionCacheRequest) (entity.CreateQuestionCacheResponse, error) {
+	id := uuid.New().String()
+	qs.log.InfoWithID(ctx, "[Service: CreateQuestionCache] Called for question id:", id)
+
+	texts, err := normalizeOptions(req)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Invalid options:", err)
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+
+	milestones, err := qs.validateMilestones(ctx, req.UserID, req.Milestones)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Invalid milestones:", err)
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+	req.Milestones = milestones
 
-func (qs *QuestionService) GetQuestionCache(ctx context.Context, questionID string) (model.QuestionCache, error) {
-	date := util.TodayDate()
+	loc, err := pollLocation(req.Timezone)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Invalid timezone:", req.Timezone)
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+	if req.FollowUp && (req.OpensAt != nil || req.ClosesAt != nil) {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Follow-up with a schedule")
+		return entity.CreateQuestionCacheResponse{}, ErrFollowUpSchedule
+	}
+	now := time.Now()
+	opensAt, closesAt, err := pollWindow(now, loc, req.OpensAt, req.ClosesAt)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Invalid schedule:", err)
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+	resp := entity.CreateQuestionCacheResponse{QuestionID: id, OpensAt: opensAt, ClosesAt: closesAt}
+
+	// Held follow-ups expire once no open poll can release them.
+	if err := qs.holdFollowUps(ctx, milestones, closesAt); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Failed to hold follow-ups:", err)
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+
+	if req.FollowUp {
+		// The window is replaced when a milestone releases the poll; until
+		// then it only decides when the held poll expires.
+		if err := qs.scheduleQuestion(ctx, id, req, model.ScheduleStatusHeld, opensAt, closesAt); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Failed to hold follow-up:", err)
+			return entity.CreateQuestionCacheResponse{}, err
+		}
+		qs.log.InfoWithID(ctx, "[Service: CreateQuestionCache] Follow-up held until a milestone opens it")
+		resp.Scheduled = true
+		resp.FollowUp = true
+		return resp, nil
+	}
+
+	if opensAt.After(now) {
+		if err := qs.scheduleQuestion(ctx, id, req, model.ScheduleStatusPending, opensAt, closesAt); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: CreateQuestionCache] Failed to schedule question:", err)
+			return entity.CreateQuestionCacheResponse{}, err
+		}
+		qs.log.InfoWithID(ctx, "[Service: CreateQuestionCache] Question scheduled to open at:", opensAt)
+		resp.Scheduled = true
+		return resp, nil
+	}
+
+	if err := qs.openQuestion(ctx, id, req, texts, opensAt, closesAt); err != nil {
+		return entity.CreateQuestionCacheResponse{}, err
+	}
+	return resp, nil
+}
+
+func (qs *QuestionService) scheduleQuestion(ctx context.Context, id string, req entity.CreateQuestionCacheRequest, status string, opensAt, closesAt time.Time) error {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	_, err = qs.scheduled.CreateScheduledQuestion(ctx, model.ScheduledQuestion{
+		QuestionID:   uuid.MustParse(id),
+		UserID:       userID,
+		QuestionText: req.Text,
+		Payload:      string(payload),
+		OpensAt:      opensAt,
+		ClosesAt:     closesAt,
+		Status:       status,
+	})
+	return err
+}
+
+// openQuestion writes a live poll to question:<date>:<id>, where date is the
+// APP_TIMEZONE day the poll opens, and announces polls created by admins.
+// question_day:<id> records that date so the poll stays reachable after
+// midnight. opensAt carries the poll's timezone.
+func (qs *QuestionService) openQuestion(ctx context.Context, id string, req entity.CreateQuestionCacheRequest, texts []string, opensAt, closesAt time.Time) error {
+	date := util.FormatDate(opensAt)
+	key := "question:" + date + ":" + id
+	qs.log.InfoWithID(ctx, "[Service: OpenQuestion] Called for key:", key)
+
+	options := make([]cacheOption, len(texts))
+	for i, text := range texts {
+		options[i] = cacheOption{ID: uuid.New().String(), Text: text}
+	}
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	followUpIDs := make([]string, len(req.Milestones))
+	for i, m := range req.Milestones {
+		followUpIDs[i] = m.FollowUpID
+	}
+
+	data := map[string]string{
+		"question_id":        id,
+		"user_id":            req.UserID,
+		"text":               req.Text,
+		"first_choice":       texts[0],
+		"second_choice":      texts[1],
+		"options":            string(encoded),
+		"total_participants": "0",
+		"milestones":         util.FormatMilestones(req.Milestones),
+		"follow_ups":         strings.Join(followUpIDs, ","),
+		"group_id":           req.GroupID,
+		"opens_at":           strconv.FormatInt(opensAt.Unix(), 10),
+		"closes_at":          strconv.FormatInt(closesAt.Unix(), 10),
+		"timezone":           opensAt.Location().String(),
+		"lock_votes":         "0",
+	}
+	if req.LockVotes {
+		data["lock_votes"] = "1"
+	}
+	for _, option := range options {
+		data[optionCountField(option.ID)] = "0"
+	}
+
+	if err := qs.cache.SetHash(ctx, key, data); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: OpenQuestion] Failed to store in Redis:", err)
+		return err
+	}
+
+	// Keep the keys past closing so the archiver can persist the final
+	// tallies. The day's set lives as long as the latest poll it can hold.
+	ttl := time.Until(pollRetention(closesAt))
+	if err := qs.cache.SetWithTTL(ctx, QuestionDayKey(id), date, ttl); err != nil {
+		return err
+	}
+
+	if err := qs.cache.AddToSet(ctx, "questions:"+date, id); err != nil {
+		return err
+	}
+
+	dayStart, _ := time.ParseInLocation("2006-01-02", date, util.Location())
+	if err := qs.cache.SetTTL(ctx, key, ttl); err != nil {
+		return err
+	}
+	if err := qs.cache.SetTTL(ctx, "questions:"+date, time.Until(dayRetention(dayStart))); err != nil {
+		return err
+	}
+
+	// Notify if admin
+	user, err := qs.userService.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: OpenQuestion] Error getting user:", err)
+		return err
+	}
+
+	if user.Role == constant.RoleAdmin {
+		// The poll is already live; a notification problem must not fail it.
+		alert := adminQuestionAlert(req.Text, texts, user.Email)
+		if err := qs.notificationService.NotifyUserOfAdminQuestion(ctx, user.Email, alert.Subject, alert.Message); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: OpenQuestion] Error queueing admin question alert:", err)
+		}
+	}
+
+	return nil
+}
+
+func (qs *QuestionService) ListScheduledQuestions(ctx context.Context, userID string) ([]entity.ScheduledQuestion, error) {
+	qs.log.InfoWithID(ctx, "[Service: ListScheduledQuestions] Called for user:", userID)
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	scheduled, err := qs.scheduled.FindUpcomingByUser(ctx, id)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: ListScheduledQuestions] Error retrieving scheduled questions:", err)
+		return nil, err
+	}
+
+	result := make([]entity.ScheduledQuestion, 0, len(scheduled))
+	for _, sq := range scheduled {
+		var req entity.CreateQuestionCacheRequest
+		if err := json.Unmarshal([]byte(sq.Payload), &req); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: ListScheduledQuestions] Skipping malformed question:", sq.QuestionID, err)
+			continue
+		}
+		texts, _ := normalizeOptions(req)
+		result = append(result, entity.ScheduledQuestion{
+			QuestionID: sq.QuestionID.String(),
+			Text:       sq.QuestionText,
+			Options:    texts,
+			OpensAt:    sq.OpensAt,
+			ClosesAt:   sq.ClosesAt,
+			CreatedAt:  sq.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (qs *QuestionService) ActivateDueQuestions(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := qs.scheduled.ClaimDue(ctx, now, constant.ScheduleBatchSize, constant.ScheduleLease)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: ActivateDueQuestions] Error claiming scheduled questions:", err)
+		return 0, err
+	}
+
+	activated := 0
+	var errs []error
+	for _, sq := range due {
+		if err := qs.activateScheduled(ctx, sq); err != nil {
+			// Left pending so a run after the lease runs out tries again.
+			qs.log.ErrorWithID(ctx, "[Service: ActivateDueQuestions] Failed to activate question:", sq.QuestionID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := qs.scheduled.MarkActive(ctx, sq.QuestionID, now); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		activated++
+	}
+	return activated, errors.Join(errs...)
+}
+
+// activateScheduled opens a claimed poll in Redis.
+func (qs *QuestionService) activateScheduled(ctx context.Context, sq model.ScheduledQuestion) error {
+	id := sq.QuestionID.String()
+
+	// A previous run may have opened the poll but failed to record it.
+	existing, err := qs.cache.GetField(ctx, "question:"+util.FormatDate(sq.OpensAt)+":"+id, "question_id")
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		return nil
+	}
+
+	var req entity.CreateQuestionCacheRequest
+	if err := json.Unmarshal([]byte(sq.Payload), &req); err != nil {
+		return fmt.Errorf("decode scheduled question %s: %w", id, err)
+	}
+	texts, err := normalizeOptions(req)
+	if err != nil {
+		return err
+	}
+	loc, err := pollLocation(req.Timezone)
+	if err != nil {
+		return err
+	}
+	return qs.openQuestion(ctx, id, req, texts, sq.OpensAt.In(loc), sq.ClosesAt.In(loc))
+}
+
+// QuestionDayKey points from a poll ID to the day of its question:<date>:<id> key.
+func QuestionDayKey(questionID string) string {
+	return "question_day:" + questionID
+}
+
+// questionDate returns the day a live poll is keyed under. Polls created
+// before question_day:<id> existed fall back to today.
+func (qs *QuestionService) questionDate(ctx context.Context, questionID string) (string, error) {
+	date, err := qs.cache.Get(ctx, QuestionDayKey(questionID))
+	if err != nil {
+		return "", err
+	}
+	if date == "" {
+		return util.TodayDate(), nil
+	}
+	return date, nil
+}
+
+func (qs *QuestionService) GetQuestionCache(ctx context.Context, questionID, viewerID string) (model.QuestionCache, error) {
+	date, err := qs.questionDate(ctx, questionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetQuestionCache] Error resolving poll day:", err)
+		return model.QuestionCache{}, err
+	}
 	key := "question:" + date + ":" + questionID
 	qs.log.InfoWithID(ctx, "[Service: GetQuestionCache] Called for key:", key)
 
-	data, err := qs.cache.GetAllHash(key)
+	data, err := qs.cache.GetAllHash(ctx, key)
 	if err != nil {
 		qs.log.ErrorWithID(ctx, "[Service: GetQuestionCache] Failed:", err)
 		return model.QuestionCache{}, err
 	}
+	if len(data) == 0 {
+		return model.QuestionCache{}, ErrQuestionNotFound
+	}
 
-	return model.QuestionCache{
-		QuestionID:        data["question_id"],
-		UserID:            data["user_id"],
-		Text:              data["text"],
-		FirstChoice:       data["first_choice"],
-		SecondChoice:      data["second_choice"],
-		FirstChoiceCount:  util.AtoiOrZero(data["first_choice_count"]),
-		SecondChoiceCount: util.AtoiOrZero(data["second_choice_count"]),
-		TotalParticipants: util.AtoiOrZero(data["total_participants"]),
-		Milestones:        data["milestones"],
-		FollowUps:         data["follow_ups"],
-		GroupID:           data["group_id"],
-	}, nil
+	q, err := questionCacheFromHash(data)
+	if err != nil {
+		return model.QuestionCache{}, err
+	}
+	if q.MyVote, err = qs.viewerVote(ctx, date, questionID, viewerID, data); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetQuestionCache] Failed to read viewer vote:", err)
+		return model.QuestionCache{}, err
+	}
+	return q, nil
 }
 
-func (qs *QuestionService) DeleteQuestionCache(ctx context.Context, questionID string) error {
-	date := util.TodayDate()
+// DeleteQuestionCache removes a live poll. Only the user_id stored in the
+// poll, a moderator or an admin may do so.
+func (qs *QuestionService) DeleteQuestionCache(ctx context.Context, actor entity.Actor, questionID string) error {
+	date, err := qs.questionDate(ctx, questionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Error resolving poll day:", err)
+		return err
+	}
 	key := "question:" + date + ":" + questionID
 	qs.log.InfoWithID(ctx, "[Service: DeleteQuestionCache] Deleting key:", key)
-	return qs.cache.DeleteKey(key)
+
+	owner, err := qs.cache.GetField(ctx, key, "user_id")
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Failed to read owner:", err)
+		return err
+	}
+	if owner == "" {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Question not found:", questionID)
+		return ErrQuestionNotFound
+	}
+	if err := authorize(actor, owner, questionAdminRoles...); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Forbidden for actor:", actor.UserID)
+		return err
+	}
+
+	if err := qs.cache.DeleteKey(ctx, key); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Failed to delete key:", err)
+		return err
+	}
+	if err := qs.cache.DeleteKey(ctx, QuestionDayKey(questionID)); err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: DeleteQuestionCache] Failed to delete day pointer:", err)
+	}
+	return nil
+}
+
+// GetAllTodayQuestions lists today's polls plus the polls opened yesterday
+// that are still running past midnight.
+func (qs *QuestionService) GetAllTodayQuestions(ctx context.Context, viewerID string) ([]model.QuestionCache, error) {
+	today, err := qs.listDayQuestions(ctx, util.TodayDate(), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	yesterday, err := qs.listDayQuestions(ctx, util.YesterdayDate(), viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := today
+	for _, question := range yesterday {
+		if question.IsOpen {
+			result = append(result, question)
+		}
+	}
+	return result, nil
 }
 
-func (qs *QuestionService) GetAllTodayQuestions(ctx context.Context) ([]model.QuestionCache, error) {
-	date := util.TodayDate()
+func (qs *QuestionService) listDayQuestions(ctx context.Context, date, viewerID string) ([]model.QuestionCache, error) {
 	key := "questions:" + date
 	qs.log.InfoWithID(ctx, "[Service: GetAllTodayQuestions] Listing from key:", key)
 
-	ids, err := qs.cache.GetSetMembers(key)
+	ids, err := qs.cache.GetSetMembers(ctx, key)
 	if err != nil {
 		return nil, err
 	}
@@ -327,24 +868,24 @@ func (qs *QuestionService) GetAllTodayQuestions(ctx context.Context) ([]model.Qu
 	var result []model.QuestionCache
 	for _, id := range ids {
 		fullKey := "question:" + date + ":" + id
-		data, err := qs.cache.GetAllHash(fullKey)
+		data, err := qs.cache.GetAllHash(ctx, fullKey)
 		if err != nil {
-			qs.log.ErrorWithID(ctx, "[Service: GetAllTodayQuestions] Failed to fetch for key:", fullKey)
+			qs.log.ErrorWithID(ctx, "[Service: GetAllTodayQuestions] Failed to fetch for key:", fullKey, err)
+			return nil, err
+		}
+		if len(data) == 0 {
+			// Deleted since it was listed.
 			continue
 		}
 
-		question := model.QuestionCache{
-			QuestionID:        data["question_id"],
-			UserID:            data["user_id"],
-			Text:              data["text"],
-			FirstChoice:       data["first_choice"],
-			SecondChoice:      data["second_choice"],
-			FirstChoiceCount:  util.AtoiOrZero(data["first_choice_count"]),
-			SecondChoiceCount: util.AtoiOrZero(data["second_choice_count"]),
-			TotalParticipants: util.AtoiOrZero(data["total_participants"]),
-			Milestones:        data["milestones"],
-			FollowUps:         data["follow_ups"],
-			GroupID:           data["group_id"],
+		question, err := questionCacheFromHash(data)
+		if err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: GetAllTodayQuestions] Failed to decode key:", fullKey, err)
+			continue
+		}
+		if question.MyVote, err = qs.viewerVote(ctx, date, id, viewerID, data); err != nil {
+			qs.log.ErrorWithID(ctx, "[Service: GetAllTodayQuestions] Failed to read viewer vote:", fullKey, err)
+			return nil, err
 		}
 		result = append(result, question)
 	}
@@ -362,3 +903,61 @@ func (qs *QuestionService) GetLastArchivedQuestion(ctx context.Context) (model.Q
 	qs.log.InfoWithID(ctx, "[Service: GetLastArchivedQuestion] Found last archived question with id:", q.QuestionID)
 	return q, nil
 }
+
+// SubscribeQuestion streams the current tallies of questionID followed by the
+// tallies published after every vote, until ctx is cancelled.
+func (qs *QuestionService) SubscribeQuestion(ctx context.Context, questionID string) (<-chan entity.VoteResponse, error) {
+	qs.log.InfoWithID(ctx, "[Service: SubscribeQuestion] Called for qid:", questionID)
+
+	// Subscribe before taking the snapshot so no vote falls in between.
+	messages, err := qs.cache.Subscribe(ctx, questionEventsChannel(questionID))
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: SubscribeQuestion] Failed to subscribe:", err)
+		return nil, err
+	}
+
+	snapshot, err := qs.GetQuestionCache(ctx, questionID, "")
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: SubscribeQuestion] Failed to load question:", err)
+		return nil, err
+	}
+
+	events := make(chan entity.VoteResponse, 1)
+	events <- entity.VoteResponse{
+		QuestionID:        snapshot.QuestionID,
+		TotalParticipants: snapshot.TotalParticipants,
+		FirstChoiceCount:  snapshot.FirstChoiceCount,
+		SecondChoiceCount: snapshot.SecondChoiceCount,
+		Options:           voteOptions(snapshot.Options),
+		NewlyRevealedIDs:  []string{},
+	}
+	go func() {
+		defer close(events)
+		for msg := range messages {
+			var event entity.VoteResponse
+			if err := json.Unmarshal([]byte(msg), &event); err != nil {
+				qs.log.ErrorWithID(ctx, "[Service: SubscribeQuestion] Dropping malformed event:", err)
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (qs *QuestionService) publishTally(ctx context.Context, resp entity.VoteResponse) error {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return qs.cache.Publish(ctx, questionEventsChannel(resp.QuestionID), string(payload))
+}
+
+// questionEventsChannel is the Redis pub/sub channel carrying a question's tallies.
+func questionEventsChannel(questionID string) string {
+	return "question_events:" + questionID
+}
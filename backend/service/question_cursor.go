@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/repository"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor, request the first page again")
+
+// questionCursor is the JSON behind an archive next_cursor. It records the
+// order it was issued for so it cannot be replayed against another sort.
+type questionCursor struct {
+	Sort      string `json:"s"`
+	Ascending bool   `json:"a,omitempty"`
+	Value     string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// encodeQuestionCursor returns the cursor of the page following q.
+func encodeQuestionCursor(filter repository.QuestionFilter, q model.Question) string {
+	cursor := questionCursor{
+		Sort:      filter.Sort,
+		Ascending: filter.Ascending,
+		ID:        q.QuestionID.String(),
+	}
+	switch filter.Sort {
+	case repository.QuestionSortDate:
+		cursor.Value = q.ArchiveDate.Format("2006-01-02")
+	case repository.QuestionSortParticipants:
+		cursor.Value = strconv.Itoa(q.TotalParticipants)
+	case repository.QuestionSortMargin:
+		cursor.Value = strconv.Itoa(q.MarginOfVictory)
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeQuestionCursor parses a next_cursor issued for the same sort order.
+func decodeQuestionCursor(filter repository.QuestionFilter, encoded string) (*repository.QuestionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor questionCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != filter.Sort || cursor.Ascending != filter.Ascending {
+		return nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(cursor.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	after := &repository.QuestionCursor{ID: id}
+	if cursor.Sort == repository.QuestionSortDate {
+		if _, err := time.Parse("2006-01-02", cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		after.Value = cursor.Value
+		return after, nil
+	}
+	count, err := strconv.Atoi(cursor.Value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	after.Value = count
+	return after, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+var (
+	ErrInvalidMilestones = fmt.Errorf("a poll can have up to %d milestones with distinct positive thresholds, each opening a different follow-up poll", constant.MaxMilestones)
+	ErrUnknownFollowUp   = errors.New("follow-up poll not found, create it first with follow_up set")
+	ErrFollowUpSchedule  = errors.New("follow-up polls open when a milestone unlocks them and cannot set opens_at or closes_at")
+)
+
+// followUpState is where a follow-up poll currently lives. status is a
+// scheduled_questions status while the poll waits there and "live" once it
+// is in Redis.
+type followUpState struct {
+	owner  string
+	status string
+}
+
+const followUpLive = "live"
+
+// validateMilestones checks the milestones of a new poll and returns them
+// ordered by threshold. Every follow-up must be a held or live poll of userID.
+func (qs *QuestionService) validateMilestones(ctx context.Context, userID string, milestones []model.Milestone) ([]model.Milestone, error) {
+	if len(milestones) == 0 {
+		return nil, nil
+	}
+	if len(milestones) > constant.MaxMilestones {
+		return nil, ErrInvalidMilestones
+	}
+
+	sorted := make([]model.Milestone, len(milestones))
+	copy(sorted, milestones)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Threshold < sorted[j].Threshold })
+
+	ids := make([]string, len(sorted))
+	seen := make(map[string]bool, len(sorted))
+	for i := range sorted {
+		sorted[i].FollowUpID = strings.TrimSpace(sorted[i].FollowUpID)
+		id := sorted[i].FollowUpID
+		if sorted[i].Threshold <= 0 || (i > 0 && sorted[i].Threshold == sorted[i-1].Threshold) || seen[id] {
+			return nil, ErrInvalidMilestones
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, ErrInvalidMilestones
+		}
+		seen[id] = true
+		ids[i] = id
+	}
+
+	states, err := qs.followUpStates(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		state, ok := states[id]
+		if !ok || state.owner != userID || state.status == model.ScheduleStatusPending {
+			qs.log.ErrorWithID(ctx, "[Service: ValidateMilestones] Unknown follow-up:", id)
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFollowUp, id)
+		}
+	}
+	return sorted, nil
+}
+
+// followUpStates looks up the given poll IDs in scheduled_questions and
+// Redis. Polls found in neither are left out.
+func (qs *QuestionService) followUpStates(ctx context.Context, ids []string) (map[string]followUpState, error) {
+	uuids := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if parsed, err := uuid.Parse(id); err == nil {
+			uuids = append(uuids, parsed)
+		}
+	}
+	scheduled, err := qs.scheduled.FindByIDs(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]followUpState, len(ids))
+	for _, sq := range scheduled {
+		if sq.Status == model.ScheduleStatusHeld || sq.Status == model.ScheduleStatusPending {
+			states[sq.QuestionID.String()] = followUpState{owner: sq.UserID.String(), status: sq.Status}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := states[id]; ok {
+			continue
+		}
+		date, err := qs.questionDate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		owner, err := qs.cache.GetField(ctx, "question:"+date+":"+id, "user_id")
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" {
+			states[id] = followUpState{owner: owner, status: followUpLive}
+		}
+	}
+	return states, nil
+}
+
+// unlockFollowUps releases the held follow-ups a vote has unlocked. They open
+// on the next scheduler run, within ScheduleInterval.
+func (qs *QuestionService) unlockFollowUps(ctx context.Context, ids []string) error {
+	uuids := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if parsed, err := uuid.Parse(id); err == nil {
+			uuids = append(uuids, parsed)
+		}
+	}
+	scheduled, err := qs.scheduled.FindByIDs(ctx, uuids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, sq := range scheduled {
+		if sq.Status != model.ScheduleStatusHeld {
+			continue
+		}
+		var req entity.CreateQuestionCacheRequest
+		if err := json.Unmarshal([]byte(sq.Payload), &req); err != nil {
+			errs = append(errs, fmt.Errorf("decode follow-up %s: %w", sq.QuestionID, err))
+			continue
+		}
+		loc, err := pollLocation(req.Timezone)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		opensAt, closesAt, err := pollWindow(now, loc, nil, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ok, err := qs.scheduled.ReleaseHeld(ctx, sq.QuestionID, opensAt, closesAt)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		qs.log.InfoWithID(ctx, "[Service: UnlockFollowUps] Released follow-up:", sq.QuestionID)
+		// The released poll's own follow-ups wait as long as it runs.
+		if err := qs.holdFollowUps(ctx, req.Milestones, closesAt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// holdFollowUps keeps the held follow-ups of milestones from expiring before
+// the poll that can release them closes at closesAt.
+func (qs *QuestionService) holdFollowUps(ctx context.Context, milestones []model.Milestone, closesAt time.Time) error {
+	ids := make([]uuid.UUID, 0, len(milestones))
+	for _, m := range milestones {
+		if parsed, err := uuid.Parse(m.FollowUpID); err == nil {
+			ids = append(ids, parsed)
+		}
+	}
+	return qs.scheduled.HoldUntil(ctx, ids, closesAt)
+}
+
+// GetFollowUps reports, for each milestone of a live poll, whether its
+// follow-up is still locked, about to open or already live.
+func (qs *QuestionService) GetFollowUps(ctx context.Context, questionID string) (entity.FollowUpsResponse, error) {
+	qs.log.InfoWithID(ctx, "[Service: GetFollowUps] Called for qid:", questionID)
+
+	date, err := qs.questionDate(ctx, questionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetFollowUps] Error resolving poll day:", err)
+		return entity.FollowUpsResponse{}, err
+	}
+	data, err := qs.cache.GetAllHash(ctx, "question:"+date+":"+questionID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetFollowUps] Error reading question:", err)
+		return entity.FollowUpsResponse{}, err
+	}
+	if len(data) == 0 {
+		return entity.FollowUpsResponse{}, ErrQuestionNotFound
+	}
+
+	milestones, err := util.ParseMilestones(data["milestones"])
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetFollowUps] Error decoding milestones:", err)
+		return entity.FollowUpsResponse{}, err
+	}
+	ids := make([]string, len(milestones))
+	for i, m := range milestones {
+		ids[i] = m.FollowUpID
+	}
+	states, err := qs.followUpStates(ctx, ids)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: GetFollowUps] Error looking up follow-ups:", err)
+		return entity.FollowUpsResponse{}, err
+	}
+
+	resp := entity.FollowUpsResponse{
+		QuestionID:        questionID,
+		TotalParticipants: util.AtoiOrZero(data["total_participants"]),
+		FollowUps:         make([]entity.FollowUpStatus, len(milestones)),
+	}
+	for i, m := range milestones {
+		// The vote script records reached thresholds; they stay reached even
+		// if retracted votes bring the total back down.
+		reached, err := qs.cache.IsSetMember(ctx, "revealed:"+questionID, strconv.Itoa(m.Threshold))
+		if err != nil {
+			return entity.FollowUpsResponse{}, err
+		}
+		status := entity.FollowUpLocked
+		if reached {
+			status = entity.FollowUpPending
+			if states[m.FollowUpID].status == followUpLive {
+				status = entity.FollowUpUnlocked
+			}
+		}
+		resp.FollowUps[i] = entity.FollowUpStatus{Threshold: m.Threshold, FollowUpID: m.FollowUpID, Status: status}
+	}
+	return resp, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+// cacheOption is one choice stored in the "options" field of a
+// question:<date>:<id> hash. Its tally lives in CountField of the same hash.
+type cacheOption struct {
+	ID         string `json:"option_id"`
+	Text       string `json:"text"`
+	CountField string `json:"-"`
+}
+
+func optionCountField(optionID string) string {
+	return "option:" + optionID + ":count"
+}
+
+// cacheOptions lists the choices of a cached question in display order.
+// Hashes written before multi-option polls only carry first/second choice,
+// so they are exposed as two options backed by the legacy counters.
+func cacheOptions(data map[string]string) ([]cacheOption, error) {
+	raw, ok := data["options"]
+	if !ok || raw == "" {
+		return []cacheOption{
+			{ID: "first_choice", Text: data["first_choice"], CountField: "first_choice_count"},
+			{ID: "second_choice", Text: data["second_choice"], CountField: "second_choice_count"},
+		}, nil
+	}
+
+	var options []cacheOption
+	if err := json.Unmarshal([]byte(raw), &options); err != nil {
+		return nil, fmt.Errorf("invalid options field: %w", err)
+	}
+	for i := range options {
+		options[i].CountField = optionCountField(options[i].ID)
+	}
+	return options, nil
+}
+
+// selectOption resolves the option a vote is cast for. Two-choice clients that
+// only send is_first_choice are mapped onto the first or second option.
+func selectOption(options []cacheOption, vote entity.VoteRequest) (cacheOption, error) {
+	if vote.OptionID == "" {
+		index := 1
+		if vote.IsFirstChoice {
+			index = 0
+		}
+		if index >= len(options) {
+			return cacheOption{}, ErrUnknownOption
+		}
+		return options[index], nil
+	}
+
+	for _, option := range options {
+		if option.ID == vote.OptionID {
+			return option, nil
+		}
+	}
+	return cacheOption{}, ErrUnknownOption
+}
+
+// normalizeOptions returns the trimmed choices of a new poll, falling back to
+// first/second choice for clients that predate multi-option polls.
+func normalizeOptions(req entity.CreateQuestionCacheRequest) ([]string, error) {
+	texts := req.Options
+	if len(texts) == 0 {
+		texts = []string{req.FirstChoice, req.SecondChoice}
+	}
+	if len(texts) < constant.MinQuestionOptions || len(texts) > constant.MaxQuestionOptions {
+		return nil, ErrInvalidOptions
+	}
+
+	normalized := make([]string, len(texts))
+	for i, text := range texts {
+		normalized[i] = strings.TrimSpace(text)
+		if normalized[i] == "" {
+			return nil, ErrInvalidOptions
+		}
+	}
+	return normalized, nil
+}
+
+// questionCacheFromHash maps a question:<date>:<id> hash onto the API model.
+// FirstChoice/SecondChoice mirror the first two options.
+func questionCacheFromHash(data map[string]string) (model.QuestionCache, error) {
+	if len(data) == 0 {
+		return model.QuestionCache{}, nil
+	}
+
+	options, err := cacheOptions(data)
+	if err != nil {
+		return model.QuestionCache{}, err
+	}
+
+	q := model.QuestionCache{
+		QuestionID:        data["question_id"],
+		UserID:            data["user_id"],
+		Text:              data["text"],
+		TotalParticipants: util.AtoiOrZero(data["total_participants"]),
+		Milestones:        data["milestones"],
+		FollowUps:         data["follow_ups"],
+		GroupID:           data["group_id"],
+		Timezone:          data["timezone"],
+		LockVotes:         data["lock_votes"] == "1",
+		Options:           make([]model.QuestionCacheOption, len(options)),
+	}
+	q.OpensAt, q.ClosesAt = pollTimes(data)
+	q.IsOpen = isPollOpen(time.Now(), q.OpensAt, q.ClosesAt)
+	for i, option := range options {
+		q.Options[i] = model.QuestionCacheOption{
+			OptionID: option.ID,
+			Text:     option.Text,
+			Count:    util.AtoiOrZero(data[option.CountField]),
+		}
+	}
+	if len(q.Options) > 0 {
+		q.FirstChoice = q.Options[0].Text
+		q.FirstChoiceCount = q.Options[0].Count
+	}
+	if len(q.Options) > 1 {
+		q.SecondChoice = q.Options[1].Text
+		q.SecondChoiceCount = q.Options[1].Count
+	}
+	return q, nil
+}
+
+func voteOptions(options []model.QuestionCacheOption) []entity.QuestionOption {
+	result := make([]entity.QuestionOption, len(options))
+	for i, option := range options {
+		result[i] = entity.QuestionOption{
+			OptionID: option.OptionID,
+			Text:     option.Text,
+			Count:    option.Count,
+		}
+	}
+	return result
+}
+
+func questionOptionTexts(options []model.QuestionOption) []string {
+	texts := make([]string, len(options))
+	for i, option := range options {
+		texts[i] = option.OptionText
+	}
+	return texts
+}
+
+// formatChoices renders choices as "Choice 1: ...\n" lines for notifications.
+func formatChoices(texts []string) string {
+	var sb strings.Builder
+	for i, text := range texts {
+		fmt.Fprintf(&sb, "Choice %d: %s\n", i+1, text)
+	}
+	return sb.String()
+}
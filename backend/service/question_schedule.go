@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+var (
+	ErrInvalidSchedule = fmt.Errorf("a poll must close after it opens, at most %d hours later, and open within %d days",
+		int(constant.MaxPollDuration.Hours()), int(constant.MaxScheduleAhead.Hours()/24))
+	ErrInvalidTimezone = errors.New("unknown timezone, use an IANA name such as Asia/Bangkok")
+)
+
+// pollLocation resolves the timezone of a poll, defaulting to APP_TIMEZONE.
+func pollLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return util.Location(), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// pollWindow resolves when a new poll opens and closes. A missing or past
+// opensAt means now and a missing closesAt means the end of the day the poll
+// opens in loc.
+func pollWindow(now time.Time, loc *time.Location, opensAt, closesAt *time.Time) (time.Time, time.Time, error) {
+	now = now.In(loc).Truncate(time.Second)
+
+	opens := now
+	if opensAt != nil && opensAt.After(now) {
+		opens = opensAt.In(loc).Truncate(time.Second)
+	}
+	if opens.Sub(now) > constant.MaxScheduleAhead {
+		return time.Time{}, time.Time{}, ErrInvalidSchedule
+	}
+
+	closes := endOfDay(opens)
+	if closesAt != nil {
+		closes = closesAt.In(loc).Truncate(time.Second)
+	}
+	if !closes.After(opens) || closes.Sub(opens) > constant.MaxPollDuration {
+		return time.Time{}, time.Time{}, ErrInvalidSchedule
+	}
+	return opens, closes, nil
+}
+
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// pollRetention returns when the Redis keys of a poll closing at closesAt may
+// expire: once the archiver has had a chance to persist its final tallies.
+func pollRetention(closesAt time.Time) time.Time {
+	return nextArchiveRun(closesAt.In(util.Location())).Add(constant.ArchiveGracePeriod)
+}
+
+// dayRetention returns when the questions:<date> set of the day starting at
+// dayStart may expire. Its polls may close up to MaxPollDuration after the
+// day ends.
+func dayRetention(dayStart time.Time) time.Time {
+	return pollRetention(endOfDay(dayStart).Add(constant.MaxPollDuration))
+}
+
+// pollTimes reads opens_at and closes_at from a question hash. Polls created
+// before scheduling have neither and stay open until their keys expire.
+func pollTimes(data map[string]string) (opensAt, closesAt *time.Time) {
+	return unixField(data["opens_at"]), unixField(data["closes_at"])
+}
+
+func unixField(raw string) *time.Time {
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(seconds, 0)
+	return &t
+}
+
+func isPollOpen(now time.Time, opensAt, closesAt *time.Time) bool {
+	if opensAt != nil && now.Before(*opensAt) {
+		return false
+	}
+	return closesAt == nil || now.Before(*closesAt)
+}
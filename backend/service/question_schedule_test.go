@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollWindowAcrossMidnight(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	now := time.Date(2025, 3, 24, 23, 30, 0, 0, bangkok)
+
+	// Without closes_at a poll ends with the day it opens.
+	opens, closes, err := pollWindow(now, bangkok, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, now, opens)
+	require.Equal(t, time.Date(2025, 3, 24, 23, 59, 59, 0, bangkok), closes)
+
+	// An explicit closes_at may run into the next day, keyed by the day it
+	// opens.
+	closesAt := time.Date(2025, 3, 25, 1, 30, 0, 0, bangkok)
+	opens, closes, err = pollWindow(now, bangkok, nil, &closesAt)
+	require.NoError(t, err)
+	require.Equal(t, closesAt, closes)
+	require.Equal(t, "2025-03-24", opens.Format("2006-01-02"))
+
+	tooLong := now.Add(25 * time.Hour)
+	_, _, err = pollWindow(now, bangkok, nil, &tooLong)
+	require.ErrorIs(t, err, ErrInvalidSchedule)
+}
+
+func TestPollReachableAfterMidnight(t *testing.T) {
+	ctx := context.Background()
+	previous := util.Location()
+	t.Cleanup(func() { util.SetLocation(previous) })
+
+	// Open the poll on a day at UTC-12, then move to UTC+14: whatever the
+	// time, today is now a later day than the one the poll is keyed under.
+	util.SetLocation(time.FixedZone("UTC-12", -12*60*60))
+	f := newQuestionFixture(t)
+	closesAt := time.Now().Add(2 * time.Hour)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{ClosesAt: &closesAt})
+	opened := util.TodayDate()
+
+	util.SetLocation(time.FixedZone("UTC+14", 14*60*60))
+	require.NotEqual(t, opened, util.TodayDate())
+
+	date, err := f.service.questionDate(ctx, poll.id)
+	require.NoError(t, err)
+	require.Equal(t, opened, date)
+
+	voter := f.vote(t, poll, 1)
+	q, err := f.service.GetQuestionCache(ctx, poll.id, voter)
+	require.NoError(t, err)
+	require.True(t, q.IsOpen)
+	require.Equal(t, 1, q.TotalParticipants)
+	require.Equal(t, poll.options[1].OptionID, q.MyVote)
+}
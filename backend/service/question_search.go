@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+)
+
+// SearchQuestions searches archived polls through Postgres full-text search
+// and live polls by substring. There are no private or group-only polls, so
+// every poll is searchable; live polls come from the same listing as
+// GetAllTodayQuestions.
+func (qs *QuestionService) SearchQuestions(ctx context.Context, query, viewerID string, limit int) (entity.QuestionSearchResult, error) {
+	qs.log.InfoWithID(ctx, "[Service: SearchQuestions] Called with query:", query)
+
+	archived, err := qs.repo.Search(ctx, query, limit)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: SearchQuestions] Error searching archive:", err)
+		return entity.QuestionSearchResult{}, err
+	}
+	if archived == nil {
+		archived = []model.Question{}
+	}
+
+	live, err := qs.GetAllTodayQuestions(ctx, viewerID)
+	if err != nil {
+		qs.log.ErrorWithID(ctx, "[Service: SearchQuestions] Error listing live polls:", err)
+		return entity.QuestionSearchResult{}, err
+	}
+	groups := searchGroups(query)
+	type match struct {
+		question model.QuestionCache
+		score    int
+	}
+	var matches []match
+	for _, question := range live {
+		if score, ok := matchLiveQuestion(question, groups); ok {
+			matches = append(matches, match{question, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := entity.QuestionSearchResult{Live: []model.QuestionCache{}, Archived: archived}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		result.Live = append(result.Live, matches[i].question)
+	}
+	qs.log.InfoWithID(ctx, "[Service: SearchQuestions] Found live and archived polls:", len(result.Live), len(result.Archived))
+	return result, nil
+}
+
+// searchGroup is one alternative of a search query: every include term must
+// appear in a poll and no exclude term may.
+type searchGroup struct {
+	include []string
+	exclude []string
+}
+
+// searchGroups parses query the way websearch_to_tsquery does for archived
+// polls: "or" separates alternatives, -word excludes a word from its
+// alternative and a "quoted phrase" must appear as written.
+func searchGroups(query string) []searchGroup {
+	var groups []searchGroup
+	var current searchGroup
+	for _, term := range searchTokens(strings.ToLower(query)) {
+		switch {
+		case term.text == "or" && !term.quoted:
+			groups = append(groups, current)
+			current = searchGroup{}
+		case term.negated:
+			current.exclude = append(current.exclude, term.text)
+		default:
+			current.include = append(current.include, term.text)
+		}
+	}
+	groups = append(groups, current)
+
+	// Like websearch_to_tsquery, an alternative needs something to match.
+	matchable := groups[:0]
+	for _, group := range groups {
+		if len(group.include) > 0 {
+			matchable = append(matchable, group)
+		}
+	}
+	return matchable
+}
+
+type searchToken struct {
+	text    string
+	quoted  bool
+	negated bool
+}
+
+// searchTokens splits query into words and quoted phrases. A leading - negates
+// either; an unterminated quote runs to the end of query.
+func searchTokens(query string) []searchToken {
+	var tokens []searchToken
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		var token searchToken
+		if strings.HasPrefix(rest, "-") && len(rest) > 1 && rest[1] != ' ' {
+			token.negated = true
+			rest = rest[1:]
+		}
+		if strings.HasPrefix(rest, `"`) {
+			token.quoted = true
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			token.text = strings.Join(strings.Fields(rest[1:end+1]), " ")
+			rest = rest[min(end+2, len(rest)):]
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			token.text = rest[:end]
+			rest = rest[end:]
+		}
+		if token.text != "" && token.text != "-" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// matchLiveQuestion reports whether question matches any of groups. The score
+// is the number of include terms of the best matching group found in the
+// question text, which ranks above options.
+func matchLiveQuestion(question model.QuestionCache, groups []searchGroup) (int, bool) {
+	text := strings.ToLower(question.Text)
+	choices := make([]string, len(question.Options))
+	for i, option := range question.Options {
+		choices[i] = strings.ToLower(option.Text)
+	}
+	all := text + "\n" + strings.Join(choices, "\n")
+
+	best, matched := 0, false
+	for _, group := range groups {
+		if score, ok := matchSearchGroup(text, all, group); ok && (!matched || score > best) {
+			best, matched = score, true
+		}
+	}
+	return best, matched
+}
+
+func matchSearchGroup(text, all string, group searchGroup) (int, bool) {
+	for _, term := range group.exclude {
+		if strings.Contains(all, term) {
+			return 0, false
+		}
+	}
+	score := 0
+	for _, term := range group.include {
+		if !strings.Contains(all, term) {
+			return 0, false
+		}
+		if strings.Contains(text, term) {
+			score++
+		}
+	}
+	return score, true
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchQuestionsLive(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	polls := map[string][]string{
+		"Do you prefer cats?":     {"Yes", "No"},
+		"Do you prefer dogs?":     {"Yes", "No"},
+		"Best pet for a flat":     {"Cat", "Hamster", "Goldfish"},
+		"Which ice cream flavour": {"Vanilla", "Chocolate"},
+		"Ice or no ice in cola":   {"Ice", "No ice", "Cream soda"},
+	}
+	for text, options := range polls {
+		f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{Text: text, Options: options})
+	}
+
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{query: "cats or dogs", want: []string{"Do you prefer cats?", "Do you prefer dogs?"}},
+		{query: "prefer cats", want: []string{"Do you prefer cats?"}},
+		{query: "prefer -dogs", want: []string{"Do you prefer cats?"}},
+		{query: "prefer -dogs or hamster", want: []string{"Do you prefer cats?", "Best pet for a flat"}},
+		{query: "goldfish", want: []string{"Best pet for a flat"}},
+		{query: `"ice cream"`, want: []string{"Which ice cream flavour"}},
+		{query: "ice cream", want: []string{"Which ice cream flavour", "Ice or no ice in cola"}},
+		{query: `ice -"ice cream"`, want: []string{"Ice or no ice in cola"}},
+		{query: "or", want: []string{}},
+		{query: "-cats", want: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			result, err := f.service.SearchQuestions(ctx, tc.query, "", 20)
+			require.NoError(t, err)
+			texts := []string{}
+			for _, q := range result.Live {
+				texts = append(texts, q.Text)
+			}
+			require.ElementsMatch(t, tc.want, texts)
+		})
+	}
+}
@@ -0,0 +1,662 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errCacheDown    = fmt.Errorf("%w: connection refused", db.ErrCacheUnavailable)
+	errDatabaseDown = errors.New("database unavailable")
+)
+
+// testPoll is a live poll opened through CreateQuestionCache.
+type testPoll struct {
+	id      string
+	options []model.QuestionCacheOption
+}
+
+func (f *questionFixture) openPoll(t *testing.T, owner model.User, req entity.CreateQuestionCacheRequest) testPoll {
+	t.Helper()
+	ctx := context.Background()
+	req.UserID = owner.UserID.String()
+	if req.Text == "" {
+		req.Text = "Tea or coffee?"
+	}
+	if req.Options == nil {
+		req.Options = []string{"Tea", "Coffee"}
+	}
+	resp, err := f.service.CreateQuestionCache(ctx, req)
+	require.NoError(t, err)
+	q, err := f.service.GetQuestionCache(ctx, resp.QuestionID, "")
+	require.NoError(t, err)
+	return testPoll{id: resp.QuestionID, options: q.Options}
+}
+
+func (f *questionFixture) vote(t *testing.T, poll testPoll, option int) string {
+	t.Helper()
+	userID := uuid.NewString()
+	_, err := f.service.VoteForQuestion(context.Background(), entity.VoteRequest{
+		UserID:     userID,
+		QuestionID: poll.id,
+		OptionID:   poll.options[option].OptionID,
+	})
+	require.NoError(t, err)
+	return userID
+}
+
+func TestVoteForQuestion(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		// setup prepares the fixture and returns the vote under test.
+		setup   func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest
+		wantErr error
+		check   func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse)
+	}{
+		{
+			name: "first vote is counted",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.False(t, resp.AlreadyVoted)
+				require.False(t, resp.Changed)
+				require.Equal(t, 1, resp.TotalParticipants)
+				require.Equal(t, 1, resp.Options[0].Count)
+				require.Equal(t, 0, resp.Options[1].Count)
+				votes := f.votes.ForQuestion(poll.id)
+				require.Len(t, votes, 1)
+				require.Equal(t, "Tea", votes[0].OptionText)
+			},
+		},
+		{
+			name: "two-choice clients vote with is_first_choice",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, IsFirstChoice: false}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.Equal(t, 0, resp.Options[0].Count)
+				require.Equal(t, 1, resp.Options[1].Count)
+			},
+		},
+		{
+			name: "duplicate vote is not counted twice",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				userID := f.vote(t, poll, 0)
+				return entity.VoteRequest{UserID: userID, QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.True(t, resp.AlreadyVoted)
+				q, err := f.service.GetQuestionCache(ctx, poll.id, "")
+				require.NoError(t, err)
+				require.Equal(t, 1, q.TotalParticipants)
+			},
+		},
+		{
+			name: "vote for another option moves the vote",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				userID := f.vote(t, poll, 0)
+				return entity.VoteRequest{UserID: userID, QuestionID: poll.id, OptionID: poll.options[1].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.True(t, resp.Changed)
+				require.Equal(t, 1, resp.TotalParticipants)
+				require.Equal(t, 0, resp.Options[0].Count)
+				require.Equal(t, 1, resp.Options[1].Count)
+				votes := f.votes.ForQuestion(poll.id)
+				require.Len(t, votes, 1)
+				require.Equal(t, "Coffee", votes[0].OptionText)
+				require.Len(t, f.notifications.ParticipantAlerts, 1, "only the first vote may alert")
+			},
+		},
+		{
+			name: "locked votes cannot move",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				locked := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{LockVotes: true})
+				userID := f.vote(t, locked, 0)
+				return entity.VoteRequest{UserID: userID, QuestionID: locked.id, OptionID: locked.options[1].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.True(t, resp.AlreadyVoted)
+			},
+		},
+		{
+			name: "unknown option",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: "missing"}
+			},
+			wantErr: ErrUnknownOption,
+		},
+		{
+			name: "unknown question",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: uuid.NewString(), OptionID: poll.options[0].OptionID}
+			},
+			wantErr: ErrQuestionNotFound,
+		},
+		{
+			name: "closed poll",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				date, err := f.service.questionDate(ctx, poll.id)
+				require.NoError(t, err)
+				closed := map[string]string{"closes_at": fmt.Sprint(time.Now().Add(-time.Minute).Unix())}
+				require.NoError(t, f.cache.SetHash(ctx, voteKeys(date, poll.id).Question, closed))
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			wantErr: ErrPollClosed,
+		},
+		{
+			name: "cache failure while reading the poll",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				f.cache.GetAllHashErr = errCacheDown
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			wantErr: db.ErrCacheUnavailable,
+		},
+		{
+			name: "cache failure while recording the vote",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				f.cache.RecordVoteErr = errCacheDown
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			wantErr: db.ErrCacheUnavailable,
+		},
+		{
+			name: "notification failure does not fail the vote",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				f.notifications.Err = errors.New("outbox unavailable")
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.Equal(t, 1, resp.TotalParticipants)
+			},
+		},
+		{
+			name: "vote history failure does not fail the vote",
+			setup: func(t *testing.T, f *questionFixture, poll testPoll) entity.VoteRequest {
+				f.votes.Err = errDatabaseDown
+				return entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+			},
+			check: func(t *testing.T, f *questionFixture, poll testPoll, resp entity.VoteResponse) {
+				require.Equal(t, 1, resp.TotalParticipants)
+				require.Empty(t, f.votes.Votes)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newQuestionFixture(t)
+			poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{})
+			vote := tc.setup(t, f, poll)
+
+			resp, err := f.service.VoteForQuestion(ctx, vote)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, f, poll, resp)
+		})
+	}
+}
+
+func TestRetractVote(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{})
+
+	first := f.vote(t, poll, 0)
+	second := f.vote(t, poll, 1)
+	require.Len(t, f.votes.ForQuestion(poll.id), 2)
+
+	resp, err := f.service.RetractVote(ctx, first, poll.id)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.TotalParticipants)
+	require.Equal(t, 0, resp.Options[0].Count)
+	require.Equal(t, 1, resp.Options[1].Count)
+
+	// Only the retracting voter's history is removed.
+	votes := f.votes.ForQuestion(poll.id)
+	require.Len(t, votes, 1)
+	require.Equal(t, second, votes[0].UserID.String())
+	require.Equal(t, "Coffee", votes[0].OptionText)
+
+	_, err = f.service.RetractVote(ctx, first, poll.id)
+	require.ErrorIs(t, err, ErrNoVote)
+}
+
+func TestVoteForQuestionThresholdAlert(t *testing.T) {
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{Text: "Cats or dogs?"})
+
+	for i := 0; i < constant.ParticipantsReachedThreshold+2; i++ {
+		f.vote(t, poll, i%2)
+	}
+	require.Equal(t, []testfakes.ParticipantsAlert{
+		{QuestionText: "Cats or dogs?", TotalParticipants: constant.ParticipantsReachedThreshold},
+	}, f.notifications.ParticipantAlerts)
+}
+
+func TestVoteForQuestionThresholdAlertOnce(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{Text: "Cats or dogs?"})
+
+	// Retracting and voting again keeps crossing the threshold.
+	vote := entity.VoteRequest{UserID: uuid.NewString(), QuestionID: poll.id, OptionID: poll.options[0].OptionID}
+	for i := 0; i < 3; i++ {
+		_, err := f.service.VoteForQuestion(ctx, vote)
+		require.NoError(t, err)
+		_, err = f.service.RetractVote(ctx, vote.UserID, poll.id)
+		require.NoError(t, err)
+	}
+	require.Len(t, f.notifications.ParticipantAlerts, 1)
+}
+
+func TestVoteForQuestionThresholdAlertRetried(t *testing.T) {
+	f := newQuestionFixture(t)
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{Text: "Cats or dogs?"})
+
+	// The alert cannot be queued when the threshold is reached, so the next
+	// vote tries again.
+	f.notifications.Err = errors.New("outbox unavailable")
+	for i := 0; i < constant.ParticipantsReachedThreshold; i++ {
+		f.vote(t, poll, 0)
+	}
+	f.notifications.Err = nil
+	f.vote(t, poll, 1)
+	f.vote(t, poll, 1)
+	require.Equal(t, []testfakes.ParticipantsAlert{
+		{QuestionText: "Cats or dogs?", TotalParticipants: constant.ParticipantsReachedThreshold},
+		{QuestionText: "Cats or dogs?", TotalParticipants: constant.ParticipantsReachedThreshold + 1},
+	}, f.notifications.ParticipantAlerts)
+}
+
+func TestVoteForQuestionMilestones(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+
+	held, err := f.service.CreateQuestionCache(ctx, entity.CreateQuestionCacheRequest{
+		Text:     "Which tea?",
+		Options:  []string{"Green", "Black"},
+		UserID:   f.user.UserID.String(),
+		FollowUp: true,
+	})
+	require.NoError(t, err)
+	require.True(t, held.FollowUp)
+	_, err = f.service.GetQuestionCache(ctx, held.QuestionID, "")
+	require.ErrorIs(t, err, ErrQuestionNotFound)
+
+	poll := f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{
+		Milestones: entity.Milestones{{Threshold: 2, FollowUpID: held.QuestionID}},
+	})
+
+	voteAndReveal := func(option int) []string {
+		resp, err := f.service.VoteForQuestion(ctx, entity.VoteRequest{
+			UserID:     uuid.NewString(),
+			QuestionID: poll.id,
+			OptionID:   poll.options[option].OptionID,
+		})
+		require.NoError(t, err)
+		return resp.NewlyRevealedIDs
+	}
+	require.Empty(t, voteAndReveal(0))
+	require.Equal(t, []string{held.QuestionID}, voteAndReveal(1))
+	require.Empty(t, voteAndReveal(0), "a milestone is revealed once")
+
+	// The vote only releases the follow-up; the scheduler opens it.
+	require.Equal(t, model.ScheduleStatusPending, f.scheduled.Questions[uuid.MustParse(held.QuestionID)].Status)
+	status, err := f.service.GetFollowUps(ctx, poll.id)
+	require.NoError(t, err)
+	require.Equal(t, entity.FollowUpPending, status.FollowUps[0].Status)
+	opened, err := f.service.ActivateDueQuestions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, opened)
+
+	followUp, err := f.service.GetQuestionCache(ctx, held.QuestionID, "")
+	require.NoError(t, err)
+	require.Equal(t, "Which tea?", followUp.Text)
+	require.True(t, followUp.IsOpen)
+	require.Equal(t, model.ScheduleStatusActive, f.scheduled.Questions[uuid.MustParse(held.QuestionID)].Status)
+
+	status, err = f.service.GetFollowUps(ctx, poll.id)
+	require.NoError(t, err)
+	require.Len(t, status.FollowUps, 1)
+	require.Equal(t, entity.FollowUpUnlocked, status.FollowUps[0].Status)
+}
+
+func TestHeldFollowUpsExpire(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+	holdFollowUp := func() uuid.UUID {
+		resp, err := f.service.CreateQuestionCache(ctx, entity.CreateQuestionCacheRequest{
+			Text:     "Which tea?",
+			Options:  []string{"Green", "Black"},
+			UserID:   f.user.UserID.String(),
+			FollowUp: true,
+		})
+		require.NoError(t, err)
+		return uuid.MustParse(resp.QuestionID)
+	}
+	orphan := holdFollowUp()
+	attached := holdFollowUp()
+
+	// The parent runs past the end of the day the follow-ups were held on.
+	closesAt := time.Now().Add(constant.MaxPollDuration)
+	f.openPoll(t, f.user, entity.CreateQuestionCacheRequest{
+		ClosesAt:   &closesAt,
+		Milestones: entity.Milestones{{Threshold: 5, FollowUpID: attached.String()}},
+	})
+	require.Equal(t, closesAt.Unix(), f.scheduled.Questions[attached].ClosesAt.Unix())
+
+	// Once their windows pass, the scheduler expires both.
+	for _, id := range []uuid.UUID{orphan, attached} {
+		sq := f.scheduled.Questions[id]
+		sq.ClosesAt = time.Now().Add(-time.Second)
+		f.scheduled.Questions[id] = sq
+	}
+	_, err := f.service.ActivateDueQuestions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, model.ScheduleStatusExpired, f.scheduled.Questions[orphan].Status)
+	require.Equal(t, model.ScheduleStatusExpired, f.scheduled.Questions[attached].Status)
+}
+
+func TestCreateQuestionCache(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name string
+		// req builds the request; UserID defaults to the fixture's user.
+		req     func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest
+		wantErr error
+		check   func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse)
+	}{
+		{
+			name: "poll opens right away",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{Text: "Tea or coffee?", Options: []string{" Tea ", "Coffee"}}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				require.False(t, resp.Scheduled)
+				q, err := f.service.GetQuestionCache(ctx, resp.QuestionID, "")
+				require.NoError(t, err)
+				require.True(t, q.IsOpen)
+				require.Equal(t, "Tea", q.Options[0].Text)
+				today, err := f.service.GetAllTodayQuestions(ctx, "")
+				require.NoError(t, err)
+				require.Len(t, today, 1)
+				require.Empty(t, f.notifications.UserAlerts)
+			},
+		},
+		{
+			name: "admin polls are announced to users",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{Text: "Tea or coffee?", Options: []string{"Tea", "Coffee"}, UserID: f.admin.UserID.String()}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				require.Len(t, f.notifications.UserAlerts, 1)
+				require.Equal(t, "Admin Question", f.notifications.UserAlerts[0].Subject)
+				require.Contains(t, f.notifications.UserAlerts[0].Message, f.admin.Email)
+			},
+		},
+		{
+			name: "announcement failure does not fail the poll",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				f.notifications.Err = errors.New("outbox unavailable")
+				return entity.CreateQuestionCacheRequest{Text: "Tea or coffee?", Options: []string{"Tea", "Coffee"}, UserID: f.admin.UserID.String()}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				_, err := f.service.GetQuestionCache(ctx, resp.QuestionID, "")
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "future opens_at schedules the poll",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				opensAt := time.Now().Add(time.Hour)
+				return entity.CreateQuestionCacheRequest{Text: "Later?", Options: []string{"Yes", "No"}, OpensAt: &opensAt}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				require.True(t, resp.Scheduled)
+				require.Equal(t, model.ScheduleStatusPending, f.scheduled.Questions[uuid.MustParse(resp.QuestionID)].Status)
+				_, err := f.service.GetQuestionCache(ctx, resp.QuestionID, "")
+				require.ErrorIs(t, err, ErrQuestionNotFound)
+			},
+		},
+		{
+			name: "follow-up is held",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{Text: "Next?", Options: []string{"Yes", "No"}, FollowUp: true}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				require.True(t, resp.FollowUp)
+				require.Equal(t, model.ScheduleStatusHeld, f.scheduled.Questions[uuid.MustParse(resp.QuestionID)].Status)
+			},
+		},
+		{
+			name: "milestones are stored in threshold order",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				first := f.holdFollowUp(t, f.user)
+				second := f.holdFollowUp(t, f.user)
+				return entity.CreateQuestionCacheRequest{
+					Text:       "Tea or coffee?",
+					Options:    []string{"Tea", "Coffee"},
+					Milestones: entity.Milestones{{Threshold: 50, FollowUpID: second}, {Threshold: 10, FollowUpID: first}},
+				}
+			},
+			check: func(t *testing.T, f *questionFixture, resp entity.CreateQuestionCacheResponse) {
+				status, err := f.service.GetFollowUps(ctx, resp.QuestionID)
+				require.NoError(t, err)
+				require.Len(t, status.FollowUps, 2)
+				require.Equal(t, 10, status.FollowUps[0].Threshold)
+				require.Equal(t, 50, status.FollowUps[1].Threshold)
+				require.Equal(t, entity.FollowUpLocked, status.FollowUps[0].Status)
+			},
+		},
+		{
+			name: "follow-up cannot be scheduled",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				opensAt := time.Now().Add(time.Hour)
+				return entity.CreateQuestionCacheRequest{Text: "Next?", Options: []string{"Yes", "No"}, FollowUp: true, OpensAt: &opensAt}
+			},
+			wantErr: ErrFollowUpSchedule,
+		},
+		{
+			name: "too few options",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{Text: "Only one?", Options: []string{"Yes", " "}}
+			},
+			wantErr: ErrInvalidOptions,
+		},
+		{
+			name: "poll closing before it opens",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				closesAt := time.Now().Add(-time.Hour)
+				return entity.CreateQuestionCacheRequest{Text: "Closed?", Options: []string{"Yes", "No"}, ClosesAt: &closesAt}
+			},
+			wantErr: ErrInvalidSchedule,
+		},
+		{
+			name: "unknown timezone",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{Text: "Where?", Options: []string{"Yes", "No"}, Timezone: "Mars/Olympus"}
+			},
+			wantErr: ErrInvalidTimezone,
+		},
+		{
+			name: "duplicate milestone thresholds",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{
+					Text:    "Tea or coffee?",
+					Options: []string{"Tea", "Coffee"},
+					Milestones: entity.Milestones{
+						{Threshold: 10, FollowUpID: f.holdFollowUp(t, f.user)},
+						{Threshold: 10, FollowUpID: f.holdFollowUp(t, f.user)},
+					},
+				}
+			},
+			wantErr: ErrInvalidMilestones,
+		},
+		{
+			name: "follow-up of another user",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				return entity.CreateQuestionCacheRequest{
+					Text:       "Tea or coffee?",
+					Options:    []string{"Tea", "Coffee"},
+					Milestones: entity.Milestones{{Threshold: 10, FollowUpID: f.holdFollowUp(t, f.admin)}},
+				}
+			},
+			wantErr: ErrUnknownFollowUp,
+		},
+		{
+			name: "cache failure",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				f.cache.SetHashErr = errCacheDown
+				return entity.CreateQuestionCacheRequest{Text: "Tea or coffee?", Options: []string{"Tea", "Coffee"}}
+			},
+			wantErr: db.ErrCacheUnavailable,
+		},
+		{
+			name: "schedule failure",
+			req: func(t *testing.T, f *questionFixture) entity.CreateQuestionCacheRequest {
+				f.scheduled.Err = errDatabaseDown
+				opensAt := time.Now().Add(time.Hour)
+				return entity.CreateQuestionCacheRequest{Text: "Later?", Options: []string{"Yes", "No"}, OpensAt: &opensAt}
+			},
+			wantErr: errDatabaseDown,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newQuestionFixture(t)
+			req := tc.req(t, f)
+			if req.UserID == "" {
+				req.UserID = f.user.UserID.String()
+			}
+
+			resp, err := f.service.CreateQuestionCache(ctx, req)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, f, resp)
+		})
+	}
+}
+
+// holdFollowUp creates a follow-up poll of owner and returns its ID.
+func (f *questionFixture) holdFollowUp(t *testing.T, owner model.User) string {
+	t.Helper()
+	resp, err := f.service.CreateQuestionCache(context.Background(), entity.CreateQuestionCacheRequest{
+		Text:     "Follow-up?",
+		Options:  []string{"Yes", "No"},
+		UserID:   owner.UserID.String(),
+		FollowUp: true,
+	})
+	require.NoError(t, err)
+	return resp.QuestionID
+}
+
+func TestCreateQuestionAnnouncesAdminQuestions(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name      string
+		creator   func(f *questionFixture) uuid.UUID
+		wantErr   string
+		wantAlert bool
+	}{
+		{name: "admin", creator: func(f *questionFixture) uuid.UUID { return f.admin.UserID }, wantAlert: true},
+		{name: "user", creator: func(f *questionFixture) uuid.UUID { return f.user.UserID }},
+		{name: "unknown creator", creator: func(f *questionFixture) uuid.UUID { return uuid.New() }, wantErr: "user not found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newQuestionFixture(t)
+			_, err := f.service.CreateQuestion(ctx, time.Now(), "Tea or coffee?", "Tea", "Coffee", 3, 2, 1, tc.creator(f), nil)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				require.Empty(t, f.questions.Questions)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, f.questions.Questions, 1)
+			if !tc.wantAlert {
+				require.Empty(t, f.questions.Outbox)
+				return
+			}
+			require.Len(t, f.questions.Outbox, 1)
+			require.Equal(t, model.OutboxKindUserAlert, f.questions.Outbox[0].Kind)
+			require.Contains(t, f.questions.Outbox[0].Payload, f.admin.Email)
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		f := newQuestionFixture(t)
+		f.questions.Err = errDatabaseDown
+		_, err := f.service.CreateQuestion(ctx, time.Now(), "Tea or coffee?", "Tea", "Coffee", 0, 0, 0, f.user.UserID, nil)
+		require.ErrorIs(t, err, errDatabaseDown)
+	})
+}
+
+func TestActivateDueQuestions(t *testing.T) {
+	ctx := context.Background()
+	f := newQuestionFixture(t)
+
+	opensAt := time.Now().Add(time.Hour)
+	resp, err := f.service.CreateQuestionCache(ctx, entity.CreateQuestionCacheRequest{
+		Text:    "Tea or coffee?",
+		Options: []string{"Tea", "Coffee"},
+		UserID:  f.user.UserID.String(),
+		OpensAt: &opensAt,
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Scheduled)
+	id := uuid.MustParse(resp.QuestionID)
+
+	// Make the poll due.
+	sq := f.scheduled.Questions[id]
+	sq.OpensAt = time.Now().Add(-time.Minute)
+	f.scheduled.Questions[id] = sq
+
+	// A failed activation keeps the poll pending under its lease.
+	f.cache.SetHashErr = errCacheDown
+	opened, err := f.service.ActivateDueQuestions(ctx)
+	require.ErrorIs(t, err, errCacheDown)
+	require.Zero(t, opened)
+	require.Equal(t, model.ScheduleStatusPending, f.scheduled.Questions[id].Status)
+	require.NotNil(t, f.scheduled.Questions[id].ClaimedUntil)
+
+	f.cache.SetHashErr = nil
+	opened, err = f.service.ActivateDueQuestions(ctx)
+	require.NoError(t, err)
+	require.Zero(t, opened, "claimed polls wait for the lease to run out")
+
+	sq = f.scheduled.Questions[id]
+	expired := time.Now().Add(-time.Second)
+	sq.ClaimedUntil = &expired
+	f.scheduled.Questions[id] = sq
+	opened, err = f.service.ActivateDueQuestions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, opened)
+	require.Equal(t, model.ScheduleStatusActive, f.scheduled.Questions[id].Status)
+	require.Nil(t, f.scheduled.Questions[id].ClaimedUntil)
+
+	q, err := f.service.GetQuestionCache(ctx, resp.QuestionID, "")
+	require.NoError(t, err)
+	require.Equal(t, "Tea or coffee?", q.Text)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+)
+
+// ISchedulerService opens scheduled polls once their opens_at has passed.
+type ISchedulerService interface {
+	// Start activates due polls every ScheduleInterval until ctx is cancelled.
+	Start(ctx context.Context)
+}
+
+type SchedulerService struct {
+	questions IQuestionService
+	log       log.LoggerInterface
+}
+
+// NewSchedulerService creates a new SchedulerService with injected question service and logger.
+func NewSchedulerService(questions IQuestionService, logger log.LoggerInterface) ISchedulerService {
+	return &SchedulerService{
+		questions: questions,
+		log:       logger,
+	}
+}
+
+func (ss *SchedulerService) Start(ctx context.Context) {
+	ss.log.InfoWithID(ctx, "[Service: Scheduler] Started")
+	ticker := time.NewTicker(constant.ScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ss.log.InfoWithID(ctx, "[Service: Scheduler] Stopped")
+			return
+		case <-ticker.C:
+			opened, err := ss.questions.ActivateDueQuestions(ctx)
+			if err != nil {
+				ss.log.ErrorWithID(ctx, "[Service: Scheduler] Activation finished with errors:", err)
+			}
+			if opened > 0 {
+				ss.log.InfoWithID(ctx, "[Service: Scheduler] Opened scheduled polls:", opened)
+			}
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/db"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+)
+
+// ITokenService issues and rotates refresh tokens, tracking them in Redis so
+// they can be revoked before they expire.
+//
+// Redis layout:
+//
+//	refresh:<jti>          "<user id>|<family>" while the token is usable
+//	refresh_used:<jti>     family of a token that was already rotated
+//	refresh_grace:<jti>    family of a token rotated less than RefreshReuseGrace ago
+//	refresh_family:<fam>   set of jtis issued in one login session
+//	refresh_user:<uid>     set of a user's session families
+//	stream_ticket:<id>     "<user id>|<role>|<question id>" until redeemed or StreamTicketTTL
+type ITokenService interface {
+	// Issue starts a new session for userID and returns its refresh token.
+	Issue(ctx context.Context, userID string) (string, error)
+	// Rotate exchanges a refresh token for a new one in the same session.
+	// Presenting a token that was already rotated revokes the whole session,
+	// unless it was rotated less than RefreshReuseGrace ago.
+	Rotate(ctx context.Context, refreshToken string) (userID, newToken string, err error)
+	// Revoke ends the session refreshToken belongs to.
+	Revoke(ctx context.Context, refreshToken string) error
+	// RevokeAll ends every session of userID.
+	RevokeAll(ctx context.Context, userID string) error
+	// IssueStreamTicket returns a single-use ticket that opens the live
+	// stream of questionID as userID. Browsers cannot set headers on
+	// EventSource or WebSocket requests, so they pass it in the query string
+	// instead of the access token.
+	IssueStreamTicket(ctx context.Context, userID, role, questionID string) (string, error)
+	// RedeemStreamTicket consumes ticket and returns the user it was issued
+	// to. It fails for unknown, expired or used tickets and for tickets issued
+	// for another question.
+	RedeemStreamTicket(ctx context.Context, ticket, questionID string) (userID, role string, err error)
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidStreamTicket = errors.New("invalid stream ticket")
+)
+
+type TokenService struct {
+	cache db.CacheService
+	jwt   *util.JWTManager
+	log   log.LoggerInterface
+}
+
+// NewTokenService creates a new TokenService with injected cache, signing keys and logger.
+func NewTokenService(cache db.CacheService, jwtManager *util.JWTManager, logger log.LoggerInterface) ITokenService {
+	return &TokenService{
+		cache: cache,
+		jwt:   jwtManager,
+		log:   logger,
+	}
+}
+
+// refreshClaims are the claims Rotate and Revoke rely on.
+type refreshClaims struct {
+	UserID string
+	JTI    string
+	Family string
+}
+
+func (ts *TokenService) Issue(ctx context.Context, userID string) (string, error) {
+	ts.log.InfoWithID(ctx, "[Service: IssueRefreshToken] Called for user:", userID)
+	return ts.issue(ctx, userID, uuid.NewString())
+}
+
+func (ts *TokenService) Rotate(ctx context.Context, refreshToken string) (string, string, error) {
+	ts.log.InfoWithID(ctx, "[Service: RotateRefreshToken] Called")
+
+	claims, err := ts.parseRefreshToken(refreshToken)
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Invalid token:", err)
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	// GETDEL makes sure only one of two concurrent requests wins the token.
+	stored, err := ts.cache.GetAndDelete(ctx, refreshKey(claims.JTI))
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to consume token:", err)
+		return "", "", err
+	}
+	if stored == "" {
+		// A concurrent refresh from another tab rotated the token a moment
+		// ago; give this one its own token in the same session. The window
+		// is used up by the first replay, so later ones count as reuse.
+		family, err := ts.cache.GetAndDelete(ctx, refreshGraceKey(claims.JTI))
+		if err != nil {
+			ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to check grace window:", err)
+			return "", "", err
+		}
+		if family != "" && family == claims.Family {
+			newToken, err := ts.issue(ctx, claims.UserID, family)
+			if err != nil {
+				return "", "", err
+			}
+			ts.log.InfoWithID(ctx, "[Service: RotateRefreshToken] Reissued token within grace window for user:", claims.UserID)
+			return claims.UserID, newToken, nil
+		}
+
+		family, err = ts.cache.Get(ctx, refreshUsedKey(claims.JTI))
+		if err != nil {
+			ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to check token reuse:", err)
+			return "", "", err
+		}
+		if family == "" {
+			ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Token revoked or unknown:", claims.JTI)
+			return "", "", ErrInvalidRefreshToken
+		}
+
+		ts.log.AuditWithID(ctx, "refresh_token_reuse",
+			"user_id", claims.UserID,
+			"jti", claims.JTI,
+			"family", family,
+		)
+		if err := ts.revokeFamily(ctx, family); err != nil {
+			ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to revoke family:", err)
+		}
+		return "", "", ErrRefreshTokenReused
+	}
+
+	userID, family, ok := strings.Cut(stored, "|")
+	if !ok || userID != claims.UserID || family != claims.Family {
+		ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Stored token does not match claims:", claims.JTI)
+		return "", "", ErrInvalidRefreshToken
+	}
+	if err := ts.cache.SetWithTTL(ctx, refreshUsedKey(claims.JTI), family, constant.RefreshTokenTTL); err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to mark token used:", err)
+		return "", "", err
+	}
+	if err := ts.cache.SetWithTTL(ctx, refreshGraceKey(claims.JTI), family, constant.RefreshReuseGrace); err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RotateRefreshToken] Failed to open grace window:", err)
+		return "", "", err
+	}
+
+	newToken, err := ts.issue(ctx, userID, family)
+	if err != nil {
+		return "", "", err
+	}
+	ts.log.InfoWithID(ctx, "[Service: RotateRefreshToken] Rotated token for user:", userID)
+	return userID, newToken, nil
+}
+
+func (ts *TokenService) Revoke(ctx context.Context, refreshToken string) error {
+	ts.log.InfoWithID(ctx, "[Service: RevokeRefreshToken] Called")
+
+	claims, err := ts.parseRefreshToken(refreshToken)
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RevokeRefreshToken] Invalid token:", err)
+		return ErrInvalidRefreshToken
+	}
+	return ts.revokeFamily(ctx, claims.Family)
+}
+
+func (ts *TokenService) RevokeAll(ctx context.Context, userID string) error {
+	ts.log.InfoWithID(ctx, "[Service: RevokeAllRefreshTokens] Called for user:", userID)
+
+	families, err := ts.cache.GetSetMembers(ctx, refreshUserKey(userID))
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RevokeAllRefreshTokens] Failed to list sessions:", err)
+		return err
+	}
+
+	var errs []error
+	for _, family := range families {
+		if err := ts.revokeFamily(ctx, family); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := ts.cache.DeleteKey(ctx, refreshUserKey(userID)); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// issue signs a refresh token in family and records it as usable.
+func (ts *TokenService) IssueStreamTicket(ctx context.Context, userID, role, questionID string) (string, error) {
+	ts.log.InfoWithID(ctx, "[Service: IssueStreamTicket] Called for question:", questionID)
+
+	ticket := uuid.NewString()
+	if err := ts.cache.SetWithTTL(ctx, streamTicketKey(ticket), userID+"|"+role+"|"+questionID, constant.StreamTicketTTL); err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: IssueStreamTicket] Failed to store ticket:", err)
+		return "", err
+	}
+	return ticket, nil
+}
+
+func (ts *TokenService) RedeemStreamTicket(ctx context.Context, ticket, questionID string) (string, string, error) {
+	ts.log.InfoWithID(ctx, "[Service: RedeemStreamTicket] Called for question:", questionID)
+
+	// GETDEL makes the ticket single-use even under concurrent requests.
+	stored, err := ts.cache.GetAndDelete(ctx, streamTicketKey(ticket))
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: RedeemStreamTicket] Failed to read ticket:", err)
+		return "", "", err
+	}
+	parts := strings.SplitN(stored, "|", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] != questionID {
+		ts.log.ErrorWithID(ctx, "[Service: RedeemStreamTicket] Unknown or mismatched ticket")
+		return "", "", ErrInvalidStreamTicket
+	}
+	return parts[0], parts[1], nil
+}
+
+func (ts *TokenService) issue(ctx context.Context, userID, family string) (string, error) {
+	token, jti, err := ts.jwt.GenerateRefreshToken(userID, family)
+	if err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: IssueRefreshToken] Error generating token:", err)
+		return "", err
+	}
+
+	if err := ts.cache.SetWithTTL(ctx, refreshKey(jti), userID+"|"+family, constant.RefreshTokenTTL); err != nil {
+		ts.log.ErrorWithID(ctx, "[Service: IssueRefreshToken] Failed to store token:", err)
+		return "", err
+	}
+	for key, member := range map[string]string{
+		refreshFamilyKey(family): jti,
+		refreshUserKey(userID):   family,
+	} {
+		if err := ts.cache.AddToSet(ctx, key, member); err != nil {
+			ts.log.ErrorWithID(ctx, "[Service: IssueRefreshToken] Failed to index token:", err)
+			return "", err
+		}
+		if err := ts.cache.SetTTL(ctx, key, constant.RefreshTokenTTL); err != nil {
+			ts.log.ErrorWithID(ctx, "[Service: IssueRefreshToken] Failed to set TTL:", err)
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// revokeFamily invalidates every token issued in one login session.
+func (ts *TokenService) revokeFamily(ctx context.Context, family string) error {
+	jtis, err := ts.cache.GetSetMembers(ctx, refreshFamilyKey(family))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, jti := range jtis {
+		for _, key := range []string{refreshKey(jti), refreshGraceKey(jti)} {
+			if err := ts.cache.DeleteKey(ctx, key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := ts.cache.DeleteKey(ctx, refreshFamilyKey(family)); err != nil {
+		errs = append(errs, err)
+	}
+	ts.log.InfoWithID(ctx, "[Service: RevokeRefreshToken] Revoked session:", family)
+	return errors.Join(errs...)
+}
+
+func (ts *TokenService) parseRefreshToken(refreshToken string) (refreshClaims, error) {
+	token, err := ts.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return refreshClaims{}, err
+	}
+	if !token.Valid {
+		return refreshClaims{}, ErrInvalidRefreshToken
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return refreshClaims{}, ErrInvalidRefreshToken
+	}
+
+	sub, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	family, _ := claims["fam"].(string)
+	if sub == "" || jti == "" || family == "" {
+		// Tokens minted before rotation carry no jti and cannot be tracked.
+		return refreshClaims{}, ErrInvalidRefreshToken
+	}
+	return refreshClaims{UserID: sub, JTI: jti, Family: family}, nil
+}
+
+func refreshKey(jti string) string          { return "refresh:" + jti }
+func refreshUsedKey(jti string) string      { return "refresh_used:" + jti }
+func refreshGraceKey(jti string) string     { return "refresh_grace:" + jti }
+func refreshFamilyKey(family string) string { return "refresh_family:" + family }
+func refreshUserKey(userID string) string   { return "refresh_user:" + userID }
+func streamTicketKey(ticket string) string  { return "stream_ticket:" + ticket }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
 	"github.com/guncv/Poll-Voting-Website/backend/log"
 	"github.com/guncv/Poll-Voting-Website/backend/model"
 	"github.com/guncv/Poll-Voting-Website/backend/repository"
@@ -16,22 +18,23 @@ type UserService interface {
 	Register(ctx context.Context, email, password string) (model.User, error)
 	Login(ctx context.Context, email, password string) (model.User, error)
 	GetUserByID(ctx context.Context, id string) (model.User, error)
-	UpdateUser(ctx context.Context, id string, newEmail, newPassword string) (model.User, error)
-	DeleteUser(ctx context.Context, id string) error
+	UpdateUser(ctx context.Context, actor entity.Actor, id string, newEmail, newPassword string) (model.User, error)
+	DeleteUser(ctx context.Context, actor entity.Actor, id string) error
+	SetRole(ctx context.Context, id, role string) (model.User, error)
 }
 
+var ErrInvalidRole = errors.New("role must be one of user, moderator, admin")
+
 type userService struct {
-	repo                repository.UserRepository
-	log                 log.LoggerInterface
-	notificationService INotificationService
+	repo repository.UserRepository
+	log  log.LoggerInterface
 }
 
 // NewUserService creates a new userService with injected repository and logger.
-func NewUserService(r repository.UserRepository, logger log.LoggerInterface, notificationService INotificationService) UserService {
+func NewUserService(r repository.UserRepository, logger log.LoggerInterface) UserService {
 	return &userService{
-		repo:                r,
-		log:                 logger,
-		notificationService: notificationService,
+		repo: r,
+		log:  logger,
 	}
 }
 
@@ -60,19 +63,16 @@ func (us *userService) Register(ctx context.Context, email, password string) (mo
 	newUser := model.User{
 		Email:    email,
 		Password: hashedPassword,
+		Role:     constant.RoleUser,
 	}
-	created, err := us.repo.CreateUser(ctx, newUser)
+	// The topic subscription is queued with the user row and delivered later,
+	// so a notification outage cannot leave a half-registered user.
+	created, err := us.repo.CreateUser(ctx, newUser, subscribeUserMessage(email))
 	if err != nil {
 		us.log.ErrorWithID(ctx, "[Service: Register] Error creating user:", err)
 		return model.User{}, err
 	}
 
-	err = us.notificationService.AddSubscriberToUserTopic(ctx, email)
-	if err != nil {
-		us.log.ErrorWithID(ctx, "[Service: Register] Error adding subscriber to admin topic:", err)
-		return model.User{}, err
-	}
-
 	us.log.InfoWithID(ctx, "[Service: Register] User created successfully with email:", email)
 	return created, nil
 }
@@ -120,9 +120,15 @@ func (us *userService) GetUserByID(ctx context.Context, id string) (model.User,
 }
 
 // UpdateUser modifies an existing user's email and/or password.
-func (us *userService) UpdateUser(ctx context.Context, id string, newEmail, newPassword string) (model.User, error) {
+// Only the user themselves or an admin may do so.
+func (us *userService) UpdateUser(ctx context.Context, actor entity.Actor, id string, newEmail, newPassword string) (model.User, error) {
 	us.log.InfoWithID(ctx, "[Service: UpdateUser] Called with id:", id)
 
+	if err := authorize(actor, id, userAdminRoles...); err != nil {
+		us.log.ErrorWithID(ctx, "[Service: UpdateUser] Forbidden for actor:", actor.UserID)
+		return model.User{}, err
+	}
+
 	u, err := us.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -157,9 +163,15 @@ func (us *userService) UpdateUser(ctx context.Context, id string, newEmail, newP
 }
 
 // DeleteUser ensures a user exists and deletes the user.
-func (us *userService) DeleteUser(ctx context.Context, id string) error {
+// Only the user themselves or an admin may do so.
+func (us *userService) DeleteUser(ctx context.Context, actor entity.Actor, id string) error {
 	us.log.InfoWithID(ctx, "[Service: DeleteUser] Called with id:", id)
 
+	if err := authorize(actor, id, userAdminRoles...); err != nil {
+		us.log.ErrorWithID(ctx, "[Service: DeleteUser] Forbidden for actor:", actor.UserID)
+		return err
+	}
+
 	// Check if the user exists.
 	_, err := us.repo.FindByID(ctx, id)
 	if err != nil {
@@ -181,3 +193,35 @@ func (us *userService) DeleteUser(ctx context.Context, id string) error {
 	us.log.InfoWithID(ctx, "[Service: DeleteUser] Successfully deleted user with id:", id)
 	return nil
 }
+
+// SetRole replaces the role of a user. Revoking a role sets it back to user.
+func (us *userService) SetRole(ctx context.Context, id, role string) (model.User, error) {
+	us.log.InfoWithID(ctx, "[Service: SetRole] Called with id:", id, "role:", role)
+
+	switch role {
+	case constant.RoleUser, constant.RoleModerator, constant.RoleAdmin:
+	default:
+		us.log.ErrorWithID(ctx, "[Service: SetRole] Invalid role:", role)
+		return model.User{}, ErrInvalidRole
+	}
+
+	u, err := us.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			us.log.ErrorWithID(ctx, "[Service: SetRole] User not found with id:", id)
+			return model.User{}, errors.New("user not found")
+		}
+		us.log.ErrorWithID(ctx, "[Service: SetRole] Error finding user:", err)
+		return model.User{}, err
+	}
+
+	u.Role = role
+	updated, err := us.repo.UpdateUser(ctx, u)
+	if err != nil {
+		us.log.ErrorWithID(ctx, "[Service: SetRole] Error updating user:", err)
+		return model.User{}, err
+	}
+
+	us.log.InfoWithID(ctx, "[Service: SetRole] Role updated for user with id:", id)
+	return updated, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
+	"github.com/guncv/Poll-Voting-Website/backend/entity"
+	"github.com/guncv/Poll-Voting-Website/backend/internal/testfakes"
+	"github.com/guncv/Poll-Voting-Website/backend/log"
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/guncv/Poll-Voting-Website/backend/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestUser returns a stored user whose password is password.
+func newTestUser(t *testing.T, email, password, role string) model.User {
+	t.Helper()
+	hashed, err := util.HashPassword(password)
+	require.NoError(t, err)
+	return model.User{UserID: uuid.New(), Email: email, Password: hashed, Role: role}
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	existing := newTestUser(t, "taken@example.com", "secret123", constant.RoleUser)
+
+	testCases := []struct {
+		name    string
+		email   string
+		fail    func(r *testfakes.UserRepo)
+		wantErr string
+	}{
+		{name: "new user", email: "new@example.com"},
+		{name: "email taken", email: existing.Email, wantErr: "user already exists"},
+		{name: "lookup failure", email: "new@example.com", fail: func(r *testfakes.UserRepo) { r.FindErr = errDatabaseDown }, wantErr: errDatabaseDown.Error()},
+		{name: "insert failure", email: "new@example.com", fail: func(r *testfakes.UserRepo) { r.CreateErr = errDatabaseDown }, wantErr: errDatabaseDown.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := testfakes.NewUserRepo(existing)
+			if tc.fail != nil {
+				tc.fail(repo)
+			}
+			us := NewUserService(repo, log.Initialize("test"))
+
+			user, err := us.Register(ctx, tc.email, "password1")
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				require.Len(t, repo.Users, 1)
+				require.Empty(t, repo.Outbox)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.email, user.Email)
+			require.Equal(t, constant.RoleUser, user.Role)
+			require.NotEqual(t, "password1", user.Password)
+			require.NoError(t, util.CheckPassword("password1", user.Password))
+
+			// The topic subscription is queued together with the user.
+			require.Len(t, repo.Outbox, 1)
+			require.Equal(t, model.OutboxKindSubscribeUser, repo.Outbox[0].Kind)
+			require.Contains(t, repo.Outbox[0].Payload, tc.email)
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	existing := newTestUser(t, "user@example.com", "secret123", constant.RoleUser)
+
+	testCases := []struct {
+		name     string
+		email    string
+		password string
+		fail     func(r *testfakes.UserRepo)
+		wantErr  string
+	}{
+		{name: "valid credentials", email: existing.Email, password: "secret123"},
+		{name: "wrong password", email: existing.Email, password: "secret124", wantErr: "invalid credentials"},
+		{name: "unknown email", email: "nobody@example.com", password: "secret123", wantErr: "invalid credentials"},
+		{name: "lookup failure", email: existing.Email, password: "secret123", fail: func(r *testfakes.UserRepo) { r.FindErr = errDatabaseDown }, wantErr: errDatabaseDown.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := testfakes.NewUserRepo(existing)
+			if tc.fail != nil {
+				tc.fail(repo)
+			}
+			us := NewUserService(repo, log.Initialize("test"))
+
+			user, err := us.Login(ctx, tc.email, tc.password)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, existing.UserID, user.UserID)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	owner := newTestUser(t, "owner@example.com", "secret123", constant.RoleUser)
+	self := entity.Actor{UserID: owner.UserID.String(), Role: constant.RoleUser}
+
+	testCases := []struct {
+		name        string
+		actor       entity.Actor
+		id          string
+		newEmail    string
+		newPassword string
+		fail        func(r *testfakes.UserRepo)
+		wantErr     string
+		wantEmail   string
+	}{
+		{name: "own email", actor: self, id: self.UserID, newEmail: "renamed@example.com", wantEmail: "renamed@example.com"},
+		{name: "own password", actor: self, id: self.UserID, newPassword: "newsecret", wantEmail: owner.Email},
+		{name: "admin edits another user", actor: entity.Actor{UserID: uuid.NewString(), Role: constant.RoleAdmin}, id: self.UserID, newEmail: "fixed@example.com", wantEmail: "fixed@example.com"},
+		{name: "moderator cannot edit another user", actor: entity.Actor{UserID: uuid.NewString(), Role: constant.RoleModerator}, id: self.UserID, newEmail: "x@example.com", wantErr: ErrForbidden.Error()},
+		{name: "user cannot edit another user", actor: entity.Actor{UserID: uuid.NewString(), Role: constant.RoleUser}, id: self.UserID, newEmail: "x@example.com", wantErr: ErrForbidden.Error()},
+		{name: "unknown user", actor: entity.Actor{UserID: uuid.NewString(), Role: constant.RoleAdmin}, id: uuid.NewString(), newEmail: "x@example.com", wantErr: "user not found"},
+		{name: "lookup failure", actor: self, id: self.UserID, newEmail: "x@example.com", fail: func(r *testfakes.UserRepo) { r.FindErr = errDatabaseDown }, wantErr: errDatabaseDown.Error()},
+		{name: "update failure", actor: self, id: self.UserID, newEmail: "x@example.com", fail: func(r *testfakes.UserRepo) { r.UpdateErr = errDatabaseDown }, wantErr: errDatabaseDown.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := testfakes.NewUserRepo(owner)
+			if tc.fail != nil {
+				tc.fail(repo)
+			}
+			us := NewUserService(repo, log.Initialize("test"))
+
+			user, err := us.UpdateUser(ctx, tc.actor, tc.id, tc.newEmail, tc.newPassword)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				require.Equal(t, owner, repo.Users[owner.UserID.String()])
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantEmail, user.Email)
+			require.Equal(t, user, repo.Users[owner.UserID.String()])
+
+			password := "secret123"
+			if tc.newPassword != "" {
+				password = tc.newPassword
+			}
+			require.NoError(t, util.CheckPassword(password, user.Password))
+		})
+	}
+}
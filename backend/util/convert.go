@@ -10,6 +10,34 @@ func AtoiOrZero(s string) int {
 	return i
 }
 
+// location is the deployment timezone poll days are counted in.
+var location = time.Local
+
+// SetLocation sets the timezone poll days are counted in. It is called once
+// at startup with the APP_TIMEZONE location.
+func SetLocation(loc *time.Location) {
+	location = loc
+}
+
+// Location returns the timezone poll days are counted in.
+func Location() *time.Location {
+	return location
+}
+
 func TodayDate() string {
-	return time.Now().Format("2006-01-02")
+	return FormatDate(time.Now())
+}
+
+// DateDaysAgo returns the poll day the given number of days before today.
+func DateDaysAgo(days int) string {
+	return FormatDate(time.Now().In(location).AddDate(0, 0, -days))
+}
+
+// FormatDate returns the poll day of t as used in Redis keys.
+func FormatDate(t time.Time) string {
+	return t.In(location).Format("2006-01-02")
+}
+
+func YesterdayDate() string {
+	return DateDaysAgo(1)
 }
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateDaysAgo(t *testing.T) {
+	previous := Location()
+	t.Cleanup(func() { SetLocation(previous) })
+	bangkok := time.FixedZone("ICT", 7*60*60)
+	SetLocation(bangkok)
+
+	today := time.Now().In(bangkok)
+	require.Equal(t, today.Format("2006-01-02"), DateDaysAgo(0))
+	require.Equal(t, TodayDate(), DateDaysAgo(0))
+	require.Equal(t, today.AddDate(0, 0, -1).Format("2006-01-02"), DateDaysAgo(1))
+	require.Equal(t, YesterdayDate(), DateDaysAgo(1))
+	require.Equal(t, today.AddDate(0, 0, -2).Format("2006-01-02"), DateDaysAgo(2))
+
+	// Days are counted in the configured timezone, not UTC.
+	require.Equal(t, "2025-03-25", FormatDate(time.Date(2025, 3, 24, 20, 0, 0, 0, time.UTC)))
+	require.Equal(t, "2025-03-24", FormatDate(time.Date(2025, 3, 24, 16, 59, 59, 0, time.UTC)))
+}
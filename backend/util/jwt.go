@@ -1,54 +1,262 @@
 package util
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/guncv/Poll-Voting-Website/backend/constant"
 )
 
-var (
-	AccessTokenSecret  = []byte("your-access-token-secret")
-	RefreshTokenSecret = []byte("your-refresh-token-secret")
+// Token types carried in the "typ" claim so an access token can never be
+// replayed as a refresh token and vice versa.
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
 )
 
-// GenerateAccessToken generates a JWT access token with a short expiry.
-func GenerateAccessToken(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(15 * time.Minute).Unix(),
+// JWTManager signs tokens with the active key and verifies them with any
+// configured key, selected by the "kid" header. Rotating keys means adding a
+// new key, switching JWT_ACTIVE_KID to it and dropping the old key once the
+// tokens it signed have expired.
+type JWTManager struct {
+	method     jwt.SigningMethod
+	activeKID  string
+	signingKey interface{}
+	verifyKeys map[string]interface{}
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWTManager loads the signing keys described by cfg.
+//
+// HS256 reads "kid:secret" pairs from JWT_SECRETS. RS256 and EdDSA read
+// PEM private keys named <kid>.pem from JWT_KEYS_DIR; retired keys can be
+// kept as public-only <kid>.pub.pem files so their tokens still verify.
+func NewJWTManager(cfg config.JWTConfig) (*JWTManager, error) {
+	m := &JWTManager{
+		activeKID:  cfg.ActiveKeyID,
+		verifyKeys: map[string]interface{}{},
+	}
+	signingKeys := map[string]interface{}{}
+
+	switch cfg.Algorithm {
+	case "", "HS256":
+		m.method = jwt.SigningMethodHS256
+		for _, pair := range strings.Split(cfg.Secrets, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || kid == "" || secret == "" {
+				continue
+			}
+			signingKeys[kid] = []byte(secret)
+			m.verifyKeys[kid] = []byte(secret)
+		}
+	case "RS256", "EdDSA":
+		if cfg.Algorithm == "RS256" {
+			m.method = jwt.SigningMethodRS256
+		} else {
+			m.method = jwt.SigningMethodEdDSA
+		}
+		if err := m.loadKeyFiles(cfg.KeysDir, signingKeys); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q, use HS256, RS256 or EdDSA", cfg.Algorithm)
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(AccessTokenSecret)
+
+	key, ok := signingKeys[m.activeKID]
+	if !ok {
+		return nil, fmt.Errorf("no %s signing key for JWT_ACTIVE_KID %q", m.method.Alg(), m.activeKID)
+	}
+	m.signingKey = key
+	return m, nil
+}
+
+// loadKeyFiles reads every <kid>.pem and <kid>.pub.pem file in dir.
+func (m *JWTManager) loadKeyFiles(dir string, signingKeys map[string]interface{}) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return fmt.Errorf("%s: no PEM block", path)
+		}
+
+		name := filepath.Base(path)
+		if kid, ok := strings.CutSuffix(name, ".pub.pem"); ok {
+			public, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := m.checkKeyType(public); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			m.verifyKeys[kid] = public
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, ".pem")
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes); rsaErr == nil {
+				private = rsaKey
+			} else {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("%s: unsupported private key", path)
+		}
+		if err := m.checkKeyType(signer.Public()); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		signingKeys[kid] = private
+		m.verifyKeys[kid] = signer.Public()
+	}
+	return nil
+}
+
+func (m *JWTManager) checkKeyType(public crypto.PublicKey) error {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		if m.method == jwt.SigningMethodRS256 {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if m.method == jwt.SigningMethodEdDSA {
+			return nil
+		}
+	}
+	return fmt.Errorf("key type %T does not match %s", public, m.method.Alg())
+}
+
+// GenerateAccessToken generates a JWT access token with a short expiry.
+// The user's role is embedded so route guards need no database lookup.
+func (m *JWTManager) GenerateAccessToken(userID, role string) (string, error) {
+	return m.sign(jwt.MapClaims{
+		"sub":  userID,
+		"role": role,
+		"typ":  tokenTypeAccess,
+		"exp":  time.Now().Add(constant.AccessTokenTTL).Unix(),
+	})
 }
 
 // GenerateRefreshToken generates a JWT refresh token with a longer expiry.
-func GenerateRefreshToken(userID string) (string, error) {
-	claims := jwt.MapClaims{
+// Every token gets a unique jti; family ties together all tokens rotated from
+// the same login. It returns the signed token and its jti.
+func (m *JWTManager) GenerateRefreshToken(userID, family string) (string, string, error) {
+	jti := uuid.NewString()
+	signed, err := m.sign(jwt.MapClaims{
 		"sub": userID,
-		"exp": time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"jti": jti,
+		"fam": family,
+		"typ": tokenTypeRefresh,
+		"exp": time.Now().Add(constant.RefreshTokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", "", err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(RefreshTokenSecret)
+	return signed, jti, nil
 }
 
 // ValidateAccessToken validates the access token.
-func ValidateAccessToken(tokenStr string) (*jwt.Token, error) {
-	return jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the signing method is HMAC.
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return AccessTokenSecret, nil
-	})
+func (m *JWTManager) ValidateAccessToken(tokenStr string) (*jwt.Token, error) {
+	return m.validate(tokenStr, tokenTypeAccess)
 }
 
 // ValidateRefreshToken validates the refresh token.
-func ValidateRefreshToken(tokenStr string) (*jwt.Token, error) {
-	return jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+func (m *JWTManager) ValidateRefreshToken(tokenStr string) (*jwt.Token, error) {
+	return m.validate(tokenStr, tokenTypeRefresh)
+}
+
+// JWKS returns the public verification keys. HS256 secrets are never
+// published, so the set is empty in that mode.
+func (m *JWTManager) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for kid, key := range m.verifyKeys {
+		switch public := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: m.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: m.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
 		}
-		return RefreshTokenSecret, nil
-	})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (m *JWTManager) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(m.method, claims)
+	token.Header["kid"] = m.activeKID
+	return token.SignedString(m.signingKey)
+}
+
+func (m *JWTManager) validate(tokenStr, tokenType string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.verifyKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{m.method.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != tokenType {
+		return nil, errors.New("unexpected token type")
+	}
+	return token, nil
 }
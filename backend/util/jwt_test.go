@@ -0,0 +1,206 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/guncv/Poll-Voting-Website/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+// keyConfigs returns three managers' configs: one signing with the retired
+// "old" key, the current one signing with "new" and still trusting "old", and
+// an unrelated one signing with "other".
+type keyConfigs func(t *testing.T) (retired, current, stranger config.JWTConfig)
+
+func hmacConfigs(t *testing.T) (config.JWTConfig, config.JWTConfig, config.JWTConfig) {
+	return config.JWTConfig{Algorithm: "HS256", ActiveKeyID: "old", Secrets: "old:first-secret"},
+		config.JWTConfig{Algorithm: "HS256", ActiveKeyID: "new", Secrets: "new:second-secret,old:first-secret"},
+		config.JWTConfig{Algorithm: "HS256", ActiveKeyID: "other", Secrets: "other:third-secret"}
+}
+
+func keyFileConfigs(alg string, generate func(t *testing.T) crypto.Signer) keyConfigs {
+	return func(t *testing.T) (config.JWTConfig, config.JWTConfig, config.JWTConfig) {
+		oldKey, newKey, otherKey := generate(t), generate(t), generate(t)
+
+		retiredDir, currentDir, strangerDir := t.TempDir(), t.TempDir(), t.TempDir()
+		writePrivateKey(t, retiredDir, "old", oldKey)
+		writePrivateKey(t, currentDir, "new", newKey)
+		writePublicKey(t, currentDir, "old", oldKey.Public())
+		writePrivateKey(t, strangerDir, "other", otherKey)
+
+		return config.JWTConfig{Algorithm: alg, ActiveKeyID: "old", KeysDir: retiredDir},
+			config.JWTConfig{Algorithm: alg, ActiveKeyID: "new", KeysDir: currentDir},
+			config.JWTConfig{Algorithm: alg, ActiveKeyID: "other", KeysDir: strangerDir}
+	}
+}
+
+func generateRSA(t *testing.T) crypto.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func generateEd25519(t *testing.T) crypto.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func writePrivateKey(t *testing.T, dir, kid string, key crypto.Signer) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, kid+".pem"), "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, kid string, key crypto.PublicKey) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, kid+".pub.pem"), "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	raw := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+}
+
+// publicKeyFromJWK rebuilds a verification key from its published JWK.
+func publicKeyFromJWK(t *testing.T, key JWK) crypto.PublicKey {
+	switch key.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		require.NoError(t, err)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		require.NoError(t, err)
+		return ed25519.PublicKey(x)
+	}
+	t.Fatalf("unexpected key type %q", key.Kty)
+	return nil
+}
+
+func TestJWTManager(t *testing.T) {
+	testCases := []struct {
+		name    string
+		alg     string
+		configs keyConfigs
+		public  bool
+	}{
+		{name: "HS256", alg: "HS256", configs: hmacConfigs},
+		{name: "RS256", alg: "RS256", configs: keyFileConfigs("RS256", generateRSA), public: true},
+		{name: "EdDSA", alg: "EdDSA", configs: keyFileConfigs("EdDSA", generateEd25519), public: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			retiredCfg, currentCfg, strangerCfg := tc.configs(t)
+			retired, err := NewJWTManager(retiredCfg)
+			require.NoError(t, err)
+			current, err := NewJWTManager(currentCfg)
+			require.NoError(t, err)
+			stranger, err := NewJWTManager(strangerCfg)
+			require.NoError(t, err)
+
+			t.Run("sign and verify", func(t *testing.T) {
+				access, err := current.GenerateAccessToken("user-1", "admin")
+				require.NoError(t, err)
+
+				token, err := current.ValidateAccessToken(access)
+				require.NoError(t, err)
+				require.Equal(t, tc.alg, token.Method.Alg())
+				require.Equal(t, "new", token.Header["kid"])
+				claims := token.Claims.(jwt.MapClaims)
+				require.Equal(t, "user-1", claims["sub"])
+				require.Equal(t, "admin", claims["role"])
+
+				refresh, jti, err := current.GenerateRefreshToken("user-1", "family-1")
+				require.NoError(t, err)
+				require.NotEmpty(t, jti)
+
+				token, err = current.ValidateRefreshToken(refresh)
+				require.NoError(t, err)
+				claims = token.Claims.(jwt.MapClaims)
+				require.Equal(t, jti, claims["jti"])
+				require.Equal(t, "family-1", claims["fam"])
+			})
+
+			t.Run("retired kid still verifies", func(t *testing.T) {
+				access, err := retired.GenerateAccessToken("user-1", "user")
+				require.NoError(t, err)
+
+				token, err := current.ValidateAccessToken(access)
+				require.NoError(t, err)
+				require.Equal(t, "old", token.Header["kid"])
+			})
+
+			t.Run("unknown kid rejected", func(t *testing.T) {
+				access, err := stranger.GenerateAccessToken("user-1", "admin")
+				require.NoError(t, err)
+
+				_, err = current.ValidateAccessToken(access)
+				require.Error(t, err)
+			})
+
+			t.Run("token type enforced", func(t *testing.T) {
+				refresh, _, err := current.GenerateRefreshToken("user-1", "family-1")
+				require.NoError(t, err)
+				_, err = current.ValidateAccessToken(refresh)
+				require.Error(t, err)
+
+				access, err := current.GenerateAccessToken("user-1", "user")
+				require.NoError(t, err)
+				_, err = current.ValidateRefreshToken(access)
+				require.Error(t, err)
+			})
+
+			t.Run("JWKS publishes public keys only", func(t *testing.T) {
+				set := current.JWKS()
+				if !tc.public {
+					require.Empty(t, set.Keys)
+					return
+				}
+
+				require.Len(t, set.Keys, 2)
+				require.Equal(t, "new", set.Keys[0].Kid)
+				require.Equal(t, "old", set.Keys[1].Kid)
+
+				raw, err := json.Marshal(set)
+				require.NoError(t, err)
+				var decoded struct {
+					Keys []map[string]interface{} `json:"keys"`
+				}
+				require.NoError(t, json.Unmarshal(raw, &decoded))
+				for _, key := range decoded.Keys {
+					for _, private := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+						require.NotContains(t, key, private)
+					}
+				}
+
+				access, err := current.GenerateAccessToken("user-1", "user")
+				require.NoError(t, err)
+				for _, key := range set.Keys {
+					require.Equal(t, tc.alg, key.Alg)
+					require.Equal(t, "sig", key.Use)
+				}
+				_, err = jwt.Parse(access, func(token *jwt.Token) (interface{}, error) {
+					return publicKeyFromJWK(t, set.Keys[0]), nil
+				}, jwt.WithValidMethods([]string{tc.alg}))
+				require.NoError(t, err)
+			})
+		})
+	}
+}
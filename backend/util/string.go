@@ -1,19 +1,49 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/guncv/Poll-Voting-Website/backend/model"
 )
 
-func ParseMilestones(raw string) map[int]string {
-	result := make(map[int]string)
-	pairs := strings.Split(raw, ",")
-	for _, p := range pairs {
-		split := strings.Split(p, ":")
-		if len(split) == 2 {
-			k, _ := strconv.Atoi(split[0])
-			result[k] = split[1]
+// ErrMalformedMilestones is returned by ParseMilestones for input that is not
+// a comma-separated list of threshold:follow-up pairs.
+var ErrMalformedMilestones = errors.New("milestones must look like \"100:<question id>,150:<question id>\"")
+
+// ParseMilestones parses the legacy "100:id1,150:id2" milestone format. An
+// empty string yields no milestones.
+func ParseMilestones(raw string) ([]model.Milestone, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var result []model.Milestone
+	for _, pair := range strings.Split(raw, ",") {
+		split := strings.Split(pair, ":")
+		if len(split) != 2 {
+			return nil, fmt.Errorf("%w: %q is not a threshold:id pair", ErrMalformedMilestones, pair)
 		}
+		threshold, err := strconv.Atoi(strings.TrimSpace(split[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number", ErrMalformedMilestones, split[0])
+		}
+		result = append(result, model.Milestone{
+			Threshold:  threshold,
+			FollowUpID: strings.TrimSpace(split[1]),
+		})
+	}
+	return result, nil
+}
+
+// FormatMilestones encodes milestones in the format ParseMilestones reads,
+// which is also what the vote script in Redis parses.
+func FormatMilestones(milestones []model.Milestone) string {
+	pairs := make([]string, len(milestones))
+	for i, m := range milestones {
+		pairs[i] = strconv.Itoa(m.Threshold) + ":" + m.FollowUpID
 	}
-	return result
+	return strings.Join(pairs, ",")
 }
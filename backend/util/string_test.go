@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/guncv/Poll-Voting-Website/backend/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMilestones(t *testing.T) {
+	milestones, err := ParseMilestones(" 100:id1, 150 : id2")
+	require.NoError(t, err)
+	require.Equal(t, []model.Milestone{
+		{Threshold: 100, FollowUpID: "id1"},
+		{Threshold: 150, FollowUpID: "id2"},
+	}, milestones)
+	require.Equal(t, "100:id1,150:id2", FormatMilestones(milestones))
+
+	milestones, err = ParseMilestones("")
+	require.NoError(t, err)
+	require.Empty(t, milestones)
+
+	for _, raw := range []string{"abc:id1", "100", "100:id1,", "100:id1:extra"} {
+		_, err := ParseMilestones(raw)
+		require.ErrorIs(t, err, ErrMalformedMilestones, raw)
+	}
+}
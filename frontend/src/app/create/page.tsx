@@ -74,7 +74,6 @@ export default function CreateQuestionPage() {
 
     try {
       let finalMilestonesStr = ''; 
-      let mainFollowUp = '';    
       let groupId = '';         // We'll only create a group_id if we have milestones
 
       // ---------------- CREATE MILESTONES (IF ANY) ----------------
@@ -89,7 +88,6 @@ export default function CreateQuestionPage() {
         });
 
         const milestoneIDsAsc: string[] = [];
-        let nextId = '';
 
         // Create them in descending order
         for (let i = sorted.length - 1; i >= 0; i--) {
@@ -98,12 +96,12 @@ export default function CreateQuestionPage() {
             throw new Error('One of your milestone questions is missing required fields.');
           }
 
+          // Follow-ups stay hidden until the main question reaches their score.
           const milestonePayload: any = {
             text: m.text,
             first_choice: m.firstChoice,
             second_choice: m.secondChoice,
-            milestones: '',
-            follow_ups: nextId,
+            follow_up: true,
           };
 
           // If we have a non-empty groupId, attach it
@@ -115,13 +113,9 @@ export default function CreateQuestionPage() {
             throw new Error('Milestone creation did not return question_id.');
           }
 
-          nextId = milestoneId;
           milestoneIDsAsc.unshift(milestoneId);
         }
 
-        // The earliest milestone is milestoneIDsAsc[0]
-        mainFollowUp = milestoneIDsAsc[0];
-
         // Build "score:questionId" parts
         const parts: string[] = [];
         for (let i = 0; i < sorted.length; i++) {
@@ -139,7 +133,6 @@ export default function CreateQuestionPage() {
         first_choice: firstChoice,
         second_choice: secondChoice,
         milestones: finalMilestonesStr,
-        follow_ups: mainFollowUp,
       };
 
       // Only attach group_id if we actually have one
@@ -23,6 +23,8 @@ interface CacheQuestion {
   milestones: string;
   follow_ups: string;
   group_id: string;
+  my_vote?: string; // option ID the caller voted for
+  options?: { option_id: string; text: string; count: number }[];
 }
 
 export default function VotingPage() {
@@ -97,6 +99,23 @@ export default function VotingPage() {
     });
   }
 
+  // Text of the option the caller voted for, or '' if they have not voted.
+  function myVoteText(q: CacheQuestion) {
+    if (!q.my_vote) return '';
+    const option = q.options?.find((o) => o.option_id === q.my_vote);
+    if (option) return option.text;
+    if (q.my_vote === 'first_choice') return q.first_choice;
+    if (q.my_vote === 'second_choice') return q.second_choice;
+    return '';
+  }
+
+  function alreadyVotedMessage(q: CacheQuestion) {
+    const text = myVoteText(q);
+    return text
+      ? `You already voted for ${text}.`
+      : 'You have already voted on this question!';
+  }
+
   async function checkAndShowMilestone(mainQ: CacheQuestion) {
     const arr = parseMilestones(mainQ.milestones);
     if (!arr.length) return;
@@ -165,6 +184,7 @@ export default function VotingPage() {
         : mainQs[0];
 
       setRandomQ(pick);
+      setHasVotedRandom(!!pick?.my_vote);
     } catch (err: any) {
       setRandomError(err.message || 'Error fetching random question.');
     }
@@ -180,7 +200,10 @@ export default function VotingPage() {
         user_id: userID,
       });
       if (resp.already_voted) {
-        setRandomError('You have already voted on this question!');
+        const updated = await fetchCacheQuestionByID(randomQ.question_id);
+        setRandomQ(updated);
+        setHasVotedRandom(true);
+        setRandomError(alreadyVotedMessage(updated));
       } else {
         setHasVotedRandom(true);
         setRandomSuccess('Vote success!');
@@ -210,6 +233,7 @@ export default function VotingPage() {
       const q = await fetchCacheQuestionByID(qid);
       if (!isMainQuestion(q)) throw new Error('Not a main question.');
       setSingleQ(q);
+      setHasVotedSingle(!!q.my_vote);
     } catch (err: any) {
       setSingleError(err.message || 'Error fetching single question.');
     }
@@ -225,7 +249,10 @@ export default function VotingPage() {
         user_id: userID,
       });
       if (resp.already_voted) {
-        setSingleError('You have already voted on this question!');
+        const updated = await fetchCacheQuestionByID(singleQ.question_id);
+        setSingleQ(updated);
+        setHasVotedSingle(true);
+        setSingleError(alreadyVotedMessage(updated));
       } else {
         setHasVotedSingle(true);
         setSingleSuccess('Vote success!');
@@ -381,6 +408,11 @@ export default function VotingPage() {
               <p>
                 <strong>Second Choice:</strong> {renderRandomChoiceLabel(false)}
               </p>
+              {myVoteText(randomQ) && (
+                <p>
+                  <strong>Your Vote:</strong> {myVoteText(randomQ)}
+                </p>
+              )}
               <div
                 style={{
                   marginTop: '1rem',
@@ -428,6 +460,11 @@ export default function VotingPage() {
                 <strong>Second Choice:</strong>{' '}
                 {renderSingleChoiceLabel(false)}
               </p>
+              {myVoteText(singleQ) && (
+                <p>
+                  <strong>Your Vote:</strong> {myVoteText(singleQ)}
+                </p>
+              )}
               <div
                 style={{
                   marginTop: '1rem',
@@ -27,7 +27,7 @@ export async function getProfile(): Promise<any> {
 
 export async function logoutUser(): Promise<void> {
   const res = await fetch(`${API_BASE}/user/logout`, {
-    method: 'GET',
+    method: 'POST',
     credentials: 'include',
   });
   if (!res.ok) {
@@ -80,6 +80,30 @@ export async function fetchCacheQuestionByID(id: string): Promise<any> {
   return apiRequest(`${API_BASE}/question/cache/${id}`, { method: 'GET' });
 }
 
+// Polls from /question/cache carry `my_vote`, the option ID the caller voted for.
+export async function fetchMyVotes(params: {
+  from?: string; // YYYY-MM-DD
+  to?: string; // YYYY-MM-DD
+  limit?: number;
+  offset?: number;
+} = {}): Promise<{
+  votes: {
+    question_id: string;
+    question_text: string;
+    option_id: string;
+    option_text: string;
+    poll_date: string;
+    voted_at: string;
+  }[];
+  next_offset: number | null;
+}> {
+  const query = new URLSearchParams();
+  Object.entries(params).forEach(([key, value]) => {
+    if (value !== undefined && value !== '') query.set(key, String(value));
+  });
+  return apiRequest(`${API_BASE}/user/me/votes?${query.toString()}`, { method: 'GET' });
+}
+
 export async function voteOnQuestion(payload: {
   question_id: string;
   is_first_choice: boolean;
@@ -102,8 +126,8 @@ export async function createQuestion(payload: {
   text: string;
   first_choice: string;
   second_choice: string;
-  milestones?: string;
-  follow_ups?: string;
+  milestones?: string | { threshold: number; follow_up_id: string }[];
+  follow_up?: boolean;
   group_id?: string;
 }): Promise<any> {
   return apiRequest(`${API_BASE}/question/cache`, {
@@ -19,7 +19,7 @@ function getAccessToken() {
 async function tryRefreshToken(): Promise<boolean> {
   try {
     const res = await fetch(`${API_BASE}/user/refresh`, {
-      method: 'GET',
+      method: 'POST',
       credentials: 'include',
     });
     if (!res.ok) {
@@ -31,7 +31,7 @@ resource "aws_lb_target_group" "backend_tg" {
   target_type = "ip"
 
   health_check {
-    path                = "/api/health"
+    path                = "/api/health/ready"
     port                = "8080"
     protocol            = "HTTP"
     interval            = 30